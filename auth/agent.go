@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AgentSigner is a crypto.Signer for an ed25519 key held by an ssh-agent, so the private key never leaves the agent.
+// Example:
+//
+//	conn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
+//	...
+//	agentSigner, err := auth.NewAgentSigner(agent.NewClient(conn), "")
+//	...
+//	signer, err := auth.NewED25519SignerFromSigner(agentSigner)
+type AgentSigner struct {
+	agent     agent.Agent
+	key       ssh.PublicKey
+	publicKey ed25519.PublicKey
+}
+
+// NewAgentSigner creates a new AgentSigner for the key of the agent with the SHA256 fingerprint
+// (e.g. "SHA256:..." as printed by ssh-add -l) or the first ed25519 key if the fingerprint is empty
+func NewAgentSigner(a agent.Agent, fingerprint string) (*AgentSigner, error) {
+	keys, err := a.List()
+	if err != nil {
+		return nil, fmt.Errorf("error listing agent keys: %w", err)
+	}
+
+	for _, key := range keys {
+		if key.Type() != ssh.KeyAlgoED25519 {
+			continue
+		}
+		if fingerprint != "" && ssh.FingerprintSHA256(key) != fingerprint {
+			continue
+		}
+
+		sshKey, err := ssh.ParsePublicKey(key.Marshal())
+		if err != nil {
+			return nil, fmt.Errorf("error parsing agent key: %w", err)
+		}
+		publicKey, ok := sshKey.(ssh.CryptoPublicKey).CryptoPublicKey().(ed25519.PublicKey)
+		if !ok {
+			continue
+		}
+		return &AgentSigner{
+			agent:     a,
+			key:       sshKey,
+			publicKey: publicKey,
+		}, nil
+	}
+
+	if fingerprint != "" {
+		return nil, fmt.Errorf("no ed25519 agent key with fingerprint %s", fingerprint)
+	}
+	return nil, errors.New("no ed25519 agent key")
+}
+
+func (s *AgentSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs the message with the agent, ed25519 does not support prehashed messages (opts.HashFunc() must be 0)
+func (s *AgentSigner) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != 0 {
+		return nil, errors.New("ed25519 agent keys can not sign prehashed messages")
+	}
+	signature, err := s.agent.Sign(s.key, message)
+	if err != nil {
+		return nil, fmt.Errorf("error signing with agent: %w", err)
+	}
+	if signature.Format != ssh.KeyAlgoED25519 {
+		return nil, fmt.Errorf("unexpected agent signature format: %s", signature.Format)
+	}
+	return signature.Blob, nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func Test_AgentSigner(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: privateKey}))
+
+	agentSigner, err := NewAgentSigner(keyring, "")
+	require.NoError(t, err)
+	signer, err := NewED25519SignerFromSigner(agentSigner)
+	require.NoError(t, err)
+
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+	assert.True(t, verifier.Verify("message", signer.Sign("message")))
+
+	// The ssh.Signer signs with the agent key as well
+	sshSignature, err := signer.SSHSigner().Sign(rand.Reader, []byte("message"))
+	require.NoError(t, err)
+	assert.NoError(t, signer.SSHSigner().PublicKey().Verify([]byte("message"), sshSignature))
+
+	// The private key is not available
+	_, err = signer.MarshalPEM()
+	assert.ErrorIs(t, err, ErrPrivateKeyUnavailable)
+
+	_, err = NewAgentSigner(keyring, ssh.FingerprintSHA256(signer.SSHSigner().PublicKey()))
+	assert.NoError(t, err)
+	_, err = NewAgentSigner(keyring, "SHA256:unknown")
+	assert.Error(t, err)
+	_, err = NewAgentSigner(agent.NewKeyring(), "")
+	assert.Error(t, err)
+}
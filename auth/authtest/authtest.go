@@ -0,0 +1,78 @@
+// Package authtest provides in-memory signer backends for testing code that uses auth.ED25519Signer
+package authtest
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pedramktb/go-base-lib/auth"
+)
+
+// Signer is an in-memory crypto.Signer that behaves like an external backend (e.g. a KMS or HSM):
+// its private key is not exposed to auth.ED25519Signer. It counts signatures and can be made to fail.
+type Signer struct {
+	key   ed25519.PrivateKey
+	count atomic.Int64
+
+	mu  sync.RWMutex
+	err error
+}
+
+// NewSigner creates a new Signer with a random key
+func NewSigner() (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{key: key}, nil
+}
+
+func (s *Signer) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s *Signer) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.mu.RLock()
+	err := s.err
+	s.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if opts.HashFunc() != 0 {
+		return nil, errors.New("prehashed messages are not supported")
+	}
+	s.count.Add(1)
+	return ed25519.Sign(s.key, message), nil
+}
+
+// Count returns the number of created signatures
+func (s *Signer) Count() int {
+	return int(s.count.Load())
+}
+
+// SetError makes all following signatures fail with err (or succeed again if err is nil)
+func (s *Signer) SetError(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// NewED25519Signer creates a new auth.ED25519Signer backed by a new Signer and fails the test on errors
+func NewED25519Signer(tb testing.TB) (*auth.ED25519Signer, *Signer) {
+	tb.Helper()
+	backend, err := NewSigner()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	signer, err := auth.NewED25519SignerFromSigner(backend)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return signer, backend
+}
@@ -0,0 +1,43 @@
+package authtest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedramktb/go-base-lib/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Signer(t *testing.T) {
+	signer, backend := NewED25519Signer(t)
+	verifier, err := auth.NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	token, err := signer.IssueToken(auth.TokenClaims{Subject: "subject"}, time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(token, auth.TokenValidation{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, backend.Count())
+
+	backend.SetError(errors.New("unavailable"))
+	_, err = signer.IssueToken(auth.TokenClaims{}, time.Minute)
+	assert.Error(t, err)
+	_, err = signer.SignErr("message")
+	assert.Error(t, err)
+	assert.Empty(t, signer.Sign("message"))
+
+	_, err = signer.MarshalPEM()
+	assert.ErrorIs(t, err, auth.ErrPrivateKeyUnavailable)
+}
+
+func Test_Signer_SignReader(t *testing.T) {
+	signer, backend := NewED25519Signer(t)
+
+	// Ed25519ph is not supported by the backend
+	_, err := signer.SignReader(strings.NewReader("message"))
+	assert.Error(t, err)
+	assert.Equal(t, 0, backend.Count())
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"runtime"
+	"sync"
+)
+
+// SignedMessage is a message and its base64 encoded signature as returned by ED25519Signer.Sign
+type SignedMessage struct {
+	Message   string
+	Signature string
+}
+
+// VerifyBatch verifies the signatures of many messages in parallel with the valid keys of the ring.
+// The returned errors have the indexes of the messages and are nil for valid signatures,
+// otherwise they are the errors of VerifyErr (e.g. ErrBadSignature).
+func (v *ED25519Verifier) VerifyBatch(messages []SignedMessage) []error {
+	errs := make([]error, len(messages))
+	// The keys are looked up once for the whole batch
+	publicKeys := v.candidates("")
+
+	workers := min(runtime.GOMAXPROCS(0), len(messages))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = verifyWithKeys(publicKeys, messages[i])
+			}
+		}()
+	}
+	for i := range messages {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
+
+func verifyWithKeys(publicKeys []ed25519.PublicKey, message SignedMessage) error {
+	signature, err := decodeSignature(message.Signature)
+	if err != nil {
+		return err
+	}
+	for _, publicKey := range publicKeys {
+		if ed25519.Verify(publicKey, []byte(message.Message), signature) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
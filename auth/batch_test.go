@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyBatch(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	messages := []SignedMessage{
+		{Message: "a", Signature: signer.Sign("a")},
+		{Message: "b", Signature: signer.Sign("other")},
+		{Message: "c", Signature: "!"},
+		{Message: "d", Signature: signer.Sign("d")},
+	}
+	for range 100 {
+		messages = append(messages, SignedMessage{Message: "e", Signature: signer.Sign("e")})
+	}
+
+	errs := verifier.VerifyBatch(messages)
+	require.Len(t, errs, len(messages))
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], ErrBadSignature)
+	assert.ErrorIs(t, errs[2], ErrInvalidBase64)
+	for _, err := range errs[3:] {
+		assert.NoError(t, err)
+	}
+
+	assert.Empty(t, verifier.VerifyBatch(nil))
+}
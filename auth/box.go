@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/pedramktb/go-base-lib/taggederror"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var ErrSealedBoxInvalid = taggederror.ErrBadRequest.Wrap(
+	taggederror.New(errors.New("invalid sealed box"), "SEALED_BOX_INVALID"),
+)
+
+// boxInfo separates the keys of sealed boxes from other uses of the shared secret
+const boxInfo = "go-base-lib/auth sealed box"
+
+// boxSignatureContext prefixes the signed message of SealAndSign, so its signatures are not valid signatures of Sign
+const boxSignatureContext = "go-base-lib/auth signed box\x00"
+
+// Seal encrypts a message to the base64 encoded ed25519 public key of the recipient and returns the base64 encoded box.
+// The key of the recipient is converted to X25519, the message is encrypted with ChaCha20-Poly1305
+// using a key derived from an ephemeral X25519 key exchange, so only the recipient can open it (see ED25519Signer.Open).
+// The box does not authenticate the sender, see ED25519Signer.SealAndSign for that.
+func Seal(recipientPublicKey string, message []byte) (string, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(recipientPublicKey)
+	if err != nil {
+		return "", ErrInvalidBase64.Wrap(fmt.Errorf("public key: %w", err))
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return "", ErrInvalidKeySize.Wrap(fmt.Errorf("public key has %d bytes", len(publicKey)))
+	}
+	box, err := seal(publicKey, message)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(box), nil
+}
+
+// SealJSON encodes v as JSON and seals it like Seal
+func SealJSON(recipientPublicKey string, v any) (string, error) {
+	message, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return Seal(recipientPublicKey, message)
+}
+
+// Open decrypts a box sealed to the master private key's public key and returns ErrSealedBoxInvalid
+// if it can not be decrypted or ErrPrivateKeyUnavailable for external signers
+func (s *ED25519Signer) Open(box string) ([]byte, error) {
+	privateKey, err := s.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	boxBytes, err := base64.StdEncoding.DecodeString(box)
+	if err != nil {
+		return nil, ErrInvalidBase64.Wrap(fmt.Errorf("sealed box: %w", err))
+	}
+	return open(privateKey, boxBytes)
+}
+
+// OpenJSON opens a box like Open and decodes its JSON message into v
+func (s *ED25519Signer) OpenJSON(box string, v any) error {
+	message, err := s.Open(box)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(message, v); err != nil {
+		return ErrSealedBoxInvalid.Wrap(err)
+	}
+	return nil
+}
+
+// SealAndSign signs the message with the master private key and seals the message with its signature
+// to the recipient, so the recipient can verify the sender with OpenAndVerify.
+// The signature covers the recipient's public key, so a box can not be resealed to another recipient,
+// and a box context, so the recipient can not present it as a signature of Sign.
+func (s *ED25519Signer) SealAndSign(recipientPublicKey string, message []byte) (string, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(recipientPublicKey)
+	if err != nil {
+		return "", ErrInvalidBase64.Wrap(fmt.Errorf("public key: %w", err))
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return "", ErrInvalidKeySize.Wrap(fmt.Errorf("public key has %d bytes", len(publicKey)))
+	}
+
+	signature, err := s.sign(slices.Concat([]byte(boxSignatureContext), publicKey, message))
+	if err != nil {
+		return "", err
+	}
+	box, err := seal(publicKey, slices.Concat(signature, message))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(box), nil
+}
+
+// OpenAndVerify opens a box of SealAndSign and verifies the signature with the keys of the sender's verifier.
+// It returns ErrBadSignature if the box was not signed by the sender.
+func (s *ED25519Signer) OpenAndVerify(box string, sender *ED25519Verifier) ([]byte, error) {
+	signed, err := s.Open(box)
+	if err != nil {
+		return nil, err
+	}
+	if len(signed) < ed25519.SignatureSize {
+		return nil, ErrSealedBoxInvalid
+	}
+	signature, message := signed[:ed25519.SignatureSize], signed[ed25519.SignatureSize:]
+	if !sender.verify("", slices.Concat([]byte(boxSignatureContext), s.publicKey, message), signature) {
+		return nil, ErrBadSignature
+	}
+	return message, nil
+}
+
+// seal encrypts the message to the ed25519 public key, the box is the ephemeral X25519 public key and the ciphertext
+func seal(recipient ed25519.PublicKey, message []byte) ([]byte, error) {
+	recipientKey, err := x25519PublicKey(recipient)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error generating ephemeral key: %w", err)
+	}
+	shared, err := ephemeral.ECDH(recipientKey)
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging keys: %w", err)
+	}
+
+	ephemeralPublicKey := ephemeral.PublicKey().Bytes()
+	aead, err := boxAEAD(shared, ephemeralPublicKey, recipientKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	// The key is unique for every box, so a zero nonce is safe
+	nonce := make([]byte, aead.NonceSize())
+	return aead.Seal(ephemeralPublicKey, nonce, message, nil), nil
+}
+
+func open(privateKey ed25519.PrivateKey, box []byte) ([]byte, error) {
+	if len(box) < 32+chacha20poly1305.Overhead {
+		return nil, ErrSealedBoxInvalid
+	}
+	key, err := x25519PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralKey, err := ecdh.X25519().NewPublicKey(box[:32])
+	if err != nil {
+		return nil, ErrSealedBoxInvalid.Wrap(err)
+	}
+	shared, err := key.ECDH(ephemeralKey)
+	if err != nil {
+		return nil, ErrSealedBoxInvalid.Wrap(err)
+	}
+
+	aead, err := boxAEAD(shared, box[:32], key.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	message, err := aead.Open(nil, nonce, box[32:], nil)
+	if err != nil {
+		return nil, ErrSealedBoxInvalid.Wrap(err)
+	}
+	return message, nil
+}
+
+// boxAEAD derives the ChaCha20-Poly1305 key of a box from the shared secret and both public keys
+func boxAEAD(shared, ephemeralPublicKey, recipientPublicKey []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, shared, slices.Concat(ephemeralPublicKey, recipientPublicKey), boxInfo, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key: %w", err)
+	}
+	return chacha20poly1305.New(key)
+}
+
+// x25519PrivateKey converts an ed25519 private key to its X25519 private key (RFC 8032 secret scalar)
+func x25519PrivateKey(privateKey ed25519.PrivateKey) (*ecdh.PrivateKey, error) {
+	h := sha512.Sum512(privateKey.Seed())
+	return ecdh.X25519().NewPrivateKey(h[:32])
+}
+
+// curve25519P is the prime 2^255 - 19 of curve25519
+var curve25519P, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", 16)
+
+// x25519PublicKey converts an ed25519 public key to its X25519 public key with the birational map u = (1 + y) / (1 - y)
+func x25519PublicKey(publicKey ed25519.PublicKey) (*ecdh.PublicKey, error) {
+	// The key is the little-endian y coordinate with the sign of x in the highest bit
+	yBytes := make([]byte, len(publicKey))
+	for i, b := range publicKey {
+		yBytes[len(publicKey)-1-i] = b
+	}
+	yBytes[0] &= 0x7f
+	y := new(big.Int).SetBytes(yBytes)
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, taggederror.ErrBadRequest.Wrap(errors.New("public key is not on the curve"))
+	}
+
+	numerator := new(big.Int).Add(big.NewInt(1), y)
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, curve25519P)
+	if denominator.Sign() == 0 {
+		return nil, taggederror.ErrBadRequest.Wrap(errors.New("public key has no X25519 equivalent"))
+	}
+	u := numerator.Mul(numerator, denominator.ModInverse(denominator, curve25519P))
+	u.Mod(u, curve25519P)
+
+	uBytes := make([]byte, 32)
+	u.FillBytes(uBytes)
+	slices.Reverse(uBytes)
+	return ecdh.X25519().NewPublicKey(uBytes)
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_X25519PublicKey(t *testing.T) {
+	signer := newTestSigner(t)
+	privateKey, err := signer.privateKey()
+	require.NoError(t, err)
+
+	// The converted public key must match the public key of the converted private key
+	x25519Private, err := x25519PrivateKey(privateKey)
+	require.NoError(t, err)
+	x25519Public, err := x25519PublicKey(signer.publicKey)
+	require.NoError(t, err)
+	assert.True(t, x25519Private.PublicKey().Equal(x25519Public))
+}
+
+func Test_Seal(t *testing.T) {
+	recipient := newTestSigner(t)
+	other := newTestSigner(t)
+
+	box, err := Seal(recipient.PublicKey(), []byte("secret"))
+	require.NoError(t, err)
+	message, err := recipient.Open(box)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(message))
+
+	_, err = other.Open(box)
+	assert.ErrorIs(t, err, ErrSealedBoxInvalid)
+
+	tampered, _ := base64.StdEncoding.DecodeString(box)
+	tampered[len(tampered)-1] ^= 1
+	_, err = recipient.Open(base64.StdEncoding.EncodeToString(tampered))
+	assert.ErrorIs(t, err, ErrSealedBoxInvalid)
+
+	_, err = recipient.Open("!")
+	assert.ErrorIs(t, err, ErrInvalidBase64)
+	_, err = Seal("AAAA", []byte("secret"))
+	assert.ErrorIs(t, err, ErrInvalidKeySize)
+
+	type config struct {
+		Password string `json:"password"`
+	}
+	box, err = SealJSON(recipient.PublicKey(), config{Password: "p"})
+	require.NoError(t, err)
+	var decoded config
+	require.NoError(t, recipient.OpenJSON(box, &decoded))
+	assert.Equal(t, "p", decoded.Password)
+}
+
+func Test_SealAndSign(t *testing.T) {
+	sender := newTestSigner(t)
+	recipient := newTestSigner(t)
+	other := newTestSigner(t)
+	senderVerifier, err := NewED25519Verifier(sender.PublicKey())
+	require.NoError(t, err)
+	otherVerifier, err := NewED25519Verifier(other.PublicKey())
+	require.NoError(t, err)
+
+	box, err := sender.SealAndSign(recipient.PublicKey(), []byte("secret"))
+	require.NoError(t, err)
+
+	message, err := recipient.OpenAndVerify(box, senderVerifier)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(message))
+
+	_, err = recipient.OpenAndVerify(box, otherVerifier)
+	assert.ErrorIs(t, err, ErrBadSignature)
+
+	// The signature in the box is not a signature of Sign over the recipient's public key and the message
+	signed, err := recipient.Open(box)
+	require.NoError(t, err)
+	recipientPublicKey, err := base64.StdEncoding.DecodeString(recipient.PublicKey())
+	require.NoError(t, err)
+	signature := base64.StdEncoding.EncodeToString(signed[:ed25519.SignatureSize])
+	assert.False(t, senderVerifier.Verify(string(recipientPublicKey)+"secret", signature))
+
+	// An unsigned box is rejected
+	box, err = Seal(recipient.PublicKey(), make([]byte, 80))
+	require.NoError(t, err)
+	_, err = recipient.OpenAndVerify(box, senderVerifier)
+	assert.ErrorIs(t, err, ErrBadSignature)
+}
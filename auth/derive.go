@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// deriveSalt separates the derived seeds of this package from other uses of the master seed
+const deriveSalt = "go-base-lib/auth ed25519 key derivation"
+
+// Derive deterministically derives a child signer from the master seed with HKDF-SHA256 for each label of the path
+// (e.g. Derive("tenant-a", "jwt") equals Derive("tenant-a") followed by Derive("jwt")).
+// The same master seed and path always result in the same key, so only the master seed has to be stored.
+// Derive fails with ErrPrivateKeyUnavailable for external signers.
+func (s *ED25519Signer) Derive(path ...string) (*ED25519Signer, error) {
+	if len(path) == 0 {
+		return nil, errors.New("empty derivation path")
+	}
+	privateKey, err := s.privateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	seed := privateKey.Seed()
+	for _, label := range path {
+		if label == "" {
+			return nil, errors.New("empty derivation label")
+		}
+		seed, err = hkdf.Key(sha256.New, seed, []byte(deriveSalt), label, ed25519.SeedSize)
+		if err != nil {
+			return nil, fmt.Errorf("error deriving key: %w", err)
+		}
+	}
+
+	return NewED25519SignerFromSigner(ed25519.NewKeyFromSeed(seed))
+}
+
+// DerivedVerifier rebuilds the verifiers of child keys derived with ED25519Signer.Derive.
+// Ed25519 keys derived with HKDF can not be derived from the master public key, so a DerivedVerifier
+// needs the master private key and only works in the service holding the root secret.
+// Every other service has to verify with the child public keys published by that service
+// (see PublicKey and ED25519Verifier.JWKS), not with a DerivedVerifier.
+type DerivedVerifier struct {
+	master *ED25519Signer
+
+	mu         sync.Mutex
+	publicKeys map[string]string
+}
+
+func NewDerivedVerifier(master *ED25519Signer) *DerivedVerifier {
+	return &DerivedVerifier{
+		master:     master,
+		publicKeys: map[string]string{},
+	}
+}
+
+// Verifier returns a new verifier of the child key of the path, the derived public keys are cached
+// and changes to the key ring of the returned verifier do not affect other verifiers
+func (d *DerivedVerifier) Verifier(path ...string) (*ED25519Verifier, error) {
+	publicKey, err := d.PublicKey(path...)
+	if err != nil {
+		return nil, err
+	}
+	return NewED25519Verifier(publicKey)
+}
+
+// PublicKey returns the base64 encoded public key of the child key of the path
+func (d *DerivedVerifier) PublicKey(path ...string) (string, error) {
+	cacheKey := strings.Join(path, "\x00")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if publicKey, ok := d.publicKeys[cacheKey]; ok {
+		return publicKey, nil
+	}
+
+	child, err := d.master.Derive(path...)
+	if err != nil {
+		return "", err
+	}
+	d.publicKeys[cacheKey] = child.PublicKey()
+	return child.PublicKey(), nil
+}
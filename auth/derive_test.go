@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Derive(t *testing.T) {
+	master := newTestSigner(t)
+
+	child, err := master.Derive("tenant-a", "jwt")
+	require.NoError(t, err)
+	assert.NotEqual(t, master.PublicKey(), child.PublicKey())
+
+	again, err := master.Derive("tenant-a", "jwt")
+	require.NoError(t, err)
+	assert.Equal(t, child.PublicKey(), again.PublicKey(), "derivation must be deterministic")
+
+	parent, err := master.Derive("tenant-a")
+	require.NoError(t, err)
+	stepwise, err := parent.Derive("jwt")
+	require.NoError(t, err)
+	assert.Equal(t, child.PublicKey(), stepwise.PublicKey(), "derivation must be hierarchical")
+
+	other, err := master.Derive("tenant-b", "jwt")
+	require.NoError(t, err)
+	assert.NotEqual(t, child.PublicKey(), other.PublicKey())
+
+	_, err = master.Derive()
+	assert.Error(t, err)
+	_, err = master.Derive("tenant-a", "")
+	assert.Error(t, err)
+}
+
+func Test_DerivedVerifier(t *testing.T) {
+	master := newTestSigner(t)
+	child, err := master.Derive("tenant-a")
+	require.NoError(t, err)
+	token, err := child.IssueToken(TokenClaims{Subject: "subject"}, time.Minute)
+	require.NoError(t, err)
+
+	derived := NewDerivedVerifier(master)
+	verifier, err := derived.Verifier("tenant-a")
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(token, TokenValidation{})
+	assert.NoError(t, err)
+
+	publicKey, err := derived.PublicKey("tenant-a")
+	require.NoError(t, err)
+	assert.Equal(t, child.PublicKey(), publicKey)
+
+	otherVerifier, err := derived.Verifier("tenant-b")
+	require.NoError(t, err)
+	_, err = otherVerifier.VerifyToken(token, TokenValidation{})
+	assert.ErrorIs(t, err, ErrTokenBadSignature)
+
+	// Every call returns a new verifier, so changing its key ring does not affect other callers
+	verifier.RemoveKey(verifier.KeyID())
+	verifier, err = derived.Verifier("tenant-a")
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(token, TokenValidation{})
+	assert.NoError(t, err)
+}
@@ -1,71 +1,155 @@
 package auth
 
 import (
+	"crypto"
 	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/pedramktb/go-base-lib/taggederror"
 	"golang.org/x/crypto/ssh"
 )
 
+var (
+	ErrInvalidBase64 = taggederror.ErrBadRequest.Wrap(
+		taggederror.New(errors.New("invalid base64 encoding"), "INVALID_BASE64"),
+	)
+	ErrInvalidKeySize = taggederror.ErrBadRequest.Wrap(
+		taggederror.New(errors.New("invalid key size"), "INVALID_KEY_SIZE"),
+	)
+	ErrInvalidSignatureSize = taggederror.ErrBadRequest.Wrap(
+		taggederror.New(errors.New("invalid signature size"), "INVALID_SIGNATURE_SIZE"),
+	)
+	ErrBadSignature = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("invalid signature"), "BAD_SIGNATURE"),
+	)
+
+	// ErrPrivateKeyUnavailable is returned by operations that need the private key of an external signer
+	ErrPrivateKeyUnavailable = errors.New("private key is not available")
+)
+
 // ED25519Verify verifies a message with a public key and a signature and returns true if the signature is valid
 func ED25519Verify(publicKey, message, signature string) bool {
+	return ED25519VerifyErr(publicKey, message, signature) == nil
+}
+
+// ED25519VerifyErr verifies a message with a public key and a signature and returns
+// ErrInvalidBase64, ErrInvalidKeySize or ErrInvalidSignatureSize if the input is malformed
+// and ErrBadSignature if the signature is not valid
+func ED25519VerifyErr(publicKey, message, signature string) error {
 	publicKeyBytes, err := base64.StdEncoding.DecodeString(publicKey)
 	if err != nil {
-		return false
+		return ErrInvalidBase64.Wrap(fmt.Errorf("public key: %w", err))
 	}
 	if len(publicKeyBytes) != ed25519.PublicKeySize {
-		return false
+		return ErrInvalidKeySize.Wrap(fmt.Errorf("public key has %d bytes", len(publicKeyBytes)))
 	}
-	signatureBytes, _ := base64.StdEncoding.DecodeString(signature)
-	if len(signatureBytes) != ed25519.SignatureSize {
-		return false
+	signatureBytes, err := decodeSignature(signature)
+	if err != nil {
+		return err
 	}
-	return ed25519.Verify(publicKeyBytes, []byte(message), signatureBytes)
+	if !ed25519.Verify(publicKeyBytes, []byte(message), signatureBytes) {
+		return ErrBadSignature
+	}
+	return nil
 }
 
 // ED25519Sign signs a message with a private key and returns the base64 encoded signature
 func ED25519Sign(privateKey, message string) string {
+	signature, _ := ED25519SignErr(privateKey, message)
+	return signature
+}
+
+// ED25519SignErr signs a message with a private key and returns the base64 encoded signature
+// or ErrInvalidBase64 or ErrInvalidKeySize if the private key is malformed
+func ED25519SignErr(privateKey, message string) (string, error) {
 	privateKeyBytes, err := base64.StdEncoding.DecodeString(privateKey)
 	if err != nil {
-		return ""
+		return "", ErrInvalidBase64.Wrap(fmt.Errorf("private key: %w", err))
 	}
 	if len(privateKeyBytes) != ed25519.PrivateKeySize {
-		return ""
+		return "", ErrInvalidKeySize.Wrap(fmt.Errorf("private key has %d bytes", len(privateKeyBytes)))
 	}
-	return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKeyBytes, []byte(message)))
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKeyBytes, []byte(message))), nil
 }
 
-// ED25519Verifier is a struct that verifies messages with a master public key
+// decodeSignature decodes a base64 encoded signature and checks its size
+func decodeSignature(signature string) ([]byte, error) {
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalidBase64.Wrap(fmt.Errorf("signature: %w", err))
+	}
+	if len(signatureBytes) != ed25519.SignatureSize {
+		return nil, ErrInvalidSignatureSize.Wrap(fmt.Errorf("signature has %d bytes", len(signatureBytes)))
+	}
+	return signatureBytes, nil
+}
+
+// ED25519Verifier is a struct that verifies messages with a key ring of public keys (see AddKey and Rotate),
+// initially containing only the master public key
 type ED25519Verifier struct {
-	masterPublicKey ed25519.PublicKey
+	mu     sync.RWMutex
+	keys   map[string]*ringKey
+	active string
 }
 
 // NewED25519Verifier creates a new ED25519Verifier with a master public key
 func NewED25519Verifier(masterPublicKey string) (*ED25519Verifier, error) {
+	v := &ED25519Verifier{}
+	if err := v.SetMasterPublicKey(masterPublicKey); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// SetMasterPublicKey replaces all keys with the master public key (e.g. from an env.Watcher subscription)
+func (v *ED25519Verifier) SetMasterPublicKey(masterPublicKey string) error {
 	masterPubKey, _ := base64.StdEncoding.DecodeString(masterPublicKey)
 	if len(masterPubKey) != ed25519.PublicKeySize {
-		return nil, fmt.Errorf("invalid master public key size")
+		return fmt.Errorf("invalid master public key size")
 	}
 
-	return &ED25519Verifier{
-		masterPublicKey: masterPubKey,
-	}, nil
+	v.setMasterPublicKey(masterPubKey)
+	return nil
 }
 
-// Verify verifies a message with a signature using the master public key and returns true if the signature is valid
+func (v *ED25519Verifier) setMasterPublicKey(masterPubKey ed25519.PublicKey) {
+	kid := keyID(masterPubKey)
+
+	v.mu.Lock()
+	v.keys = map[string]*ringKey{kid: {publicKey: masterPubKey, state: KeyStateActive}}
+	v.active = kid
+	v.mu.Unlock()
+}
+
+// Verify verifies a message with a signature using the active key (or any other valid key of the ring)
+// and returns true if the signature is valid
 func (v *ED25519Verifier) Verify(message, signature string) bool {
-	signatureBytes, _ := base64.StdEncoding.DecodeString(signature)
-	if len(signatureBytes) != ed25519.SignatureSize {
-		return false
+	return v.VerifyErr(message, signature) == nil
+}
+
+// VerifyErr is like Verify but returns ErrInvalidBase64 or ErrInvalidSignatureSize if the signature is malformed
+// and ErrBadSignature if it is not valid
+func (v *ED25519Verifier) VerifyErr(message, signature string) error {
+	signatureBytes, err := decodeSignature(signature)
+	if err != nil {
+		return err
 	}
-	return ed25519.Verify(v.masterPublicKey, []byte(message), signatureBytes)
+	if !v.verify("", []byte(message), signatureBytes) {
+		return ErrBadSignature
+	}
+	return nil
 }
 
-// ED25519Signer is a struct that signs messages with a master private key
+// ED25519Signer is a struct that signs messages with a master private key,
+// which is either held in memory or by an external crypto.Signer (e.g. an AgentSigner, KMS or HSM)
 type ED25519Signer struct {
-	masterPrivateKey ed25519.PrivateKey
-	sshSigner        ssh.Signer
+	signer    crypto.Signer
+	publicKey ed25519.PublicKey
+	sshSigner ssh.Signer
 }
 
 // NewED25519Signer creates a new ED25519Signer (and ssh.Signer) with a master private key
@@ -75,36 +159,86 @@ func NewED25519Signer(masterPrivateKey string) (*ED25519Signer, error) {
 		return nil, fmt.Errorf("invalid master private key size")
 	}
 
-	key := ed25519.NewKeyFromSeed(masterSeed)
+	return NewED25519SignerFromSigner(ed25519.NewKeyFromSeed(masterSeed))
+}
+
+// NewED25519SignerFromSigner creates a new ED25519Signer (and ssh.Signer) with a crypto.Signer of an ed25519 key.
+// The signer has to sign messages without prehashing (crypto.Hash(0)) like ed25519.PrivateKey.
+// Operations that need the private key itself (e.g. MarshalPEM) fail with ErrPrivateKeyUnavailable
+// if the signer is not an ed25519.PrivateKey.
+func NewED25519SignerFromSigner(signer crypto.Signer) (*ED25519Signer, error) {
+	publicKey, ok := signer.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type: %T", signer.Public())
+	}
 
-	sshSigner, err := ssh.NewSignerFromSigner(key)
+	sshSigner, err := ssh.NewSignerFromSigner(signer)
 	if err != nil {
 		return nil, fmt.Errorf("error creating ssh signer: %v", err)
 	}
 
 	return &ED25519Signer{
-		masterPrivateKey: key,
-		sshSigner:        sshSigner,
+		signer:    signer,
+		publicKey: publicKey,
+		sshSigner: sshSigner,
 	}, nil
 }
 
+// privateKey returns the master private key or ErrPrivateKeyUnavailable if it is held by an external signer
+func (s *ED25519Signer) privateKey() (ed25519.PrivateKey, error) {
+	key, ok := s.signer.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrPrivateKeyUnavailable
+	}
+	return key, nil
+}
+
+// sign signs a message with the master private key
+func (s *ED25519Signer) sign(message []byte) ([]byte, error) {
+	signature, err := s.signer.Sign(rand.Reader, message, crypto.Hash(0))
+	if err != nil {
+		return nil, fmt.Errorf("error signing message: %w", err)
+	}
+	return signature, nil
+}
+
 // PublicKey returns the master private key's public key as a base64 encoded string
 func (s *ED25519Signer) PublicKey() string {
-	return base64.StdEncoding.EncodeToString(s.masterPrivateKey.Public().(ed25519.PublicKey))
+	return base64.StdEncoding.EncodeToString(s.publicKey)
 }
 
 // Sign signs a message with the master private key and returns the base64 encoded signature
+// or an empty string if an external signer fails
 func (s *ED25519Signer) Sign(message string) string {
-	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.masterPrivateKey, []byte(message)))
+	signature, _ := s.SignErr(message)
+	return signature
+}
+
+// SignErr signs a message with the master private key and returns the base64 encoded signature
+func (s *ED25519Signer) SignErr(message string) (string, error) {
+	signature, err := s.sign([]byte(message))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
 }
 
 // Verify verifies a message with a signature using the master private key's public key
 func (s *ED25519Signer) Verify(message, signature string) bool {
-	signatureBytes, _ := base64.StdEncoding.DecodeString(signature)
-	if len(signatureBytes) != ed25519.SignatureSize {
-		return false
+	return s.VerifyErr(message, signature) == nil
+}
+
+// VerifyErr is like Verify but returns ErrInvalidBase64 or ErrInvalidSignatureSize if the signature is malformed
+// and ErrBadSignature if it is not valid
+func (s *ED25519Signer) VerifyErr(message, signature string) error {
+	signatureBytes, err := decodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(s.publicKey, []byte(message), signatureBytes) {
+		return ErrBadSignature
 	}
-	return ed25519.Verify(s.masterPrivateKey.Public().(ed25519.PublicKey), []byte(message), signatureBytes)
+	return nil
 }
 
 // SSHSigner returns the ssh.Signer based on the master private key
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/pedramktb/go-base-lib/taggederror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ED25519VerifyErr(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	encodedPublicKey := base64.StdEncoding.EncodeToString(publicKey)
+	signature, err := ED25519SignErr(base64.StdEncoding.EncodeToString(privateKey), "message")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		publicKey string
+		message   string
+		signature string
+		err       error
+		code      int
+	}{
+		{
+			name:      "valid",
+			publicKey: encodedPublicKey,
+			message:   "message",
+			signature: signature,
+		},
+		{
+			name:      "invalid public key base64",
+			publicKey: "!",
+			message:   "message",
+			signature: signature,
+			err:       ErrInvalidBase64,
+			code:      400,
+		},
+		{
+			name:      "invalid public key size",
+			publicKey: base64.StdEncoding.EncodeToString(publicKey[:16]),
+			message:   "message",
+			signature: signature,
+			err:       ErrInvalidKeySize,
+			code:      400,
+		},
+		{
+			name:      "invalid signature base64",
+			publicKey: encodedPublicKey,
+			message:   "message",
+			signature: "!",
+			err:       ErrInvalidBase64,
+			code:      400,
+		},
+		{
+			name:      "invalid signature size",
+			publicKey: encodedPublicKey,
+			message:   "message",
+			signature: base64.StdEncoding.EncodeToString([]byte("short")),
+			err:       ErrInvalidSignatureSize,
+			code:      400,
+		},
+		{
+			name:      "bad signature",
+			publicKey: encodedPublicKey,
+			message:   "other",
+			signature: signature,
+			err:       ErrBadSignature,
+			code:      401,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ED25519VerifyErr(tt.publicKey, tt.message, tt.signature)
+			assert.Equal(t, tt.err == nil, ED25519Verify(tt.publicKey, tt.message, tt.signature))
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+			var taggedErr *taggederror.Error
+			require.ErrorAs(t, err, &taggedErr)
+			assert.Equal(t, tt.code, taggedErr.Code())
+		})
+	}
+}
+
+func Test_ED25519SignErr(t *testing.T) {
+	_, err := ED25519SignErr("!", "message")
+	assert.ErrorIs(t, err, ErrInvalidBase64)
+	_, err = ED25519SignErr(base64.StdEncoding.EncodeToString([]byte("short")), "message")
+	assert.ErrorIs(t, err, ErrInvalidKeySize)
+	assert.Empty(t, ED25519Sign("!", "message"))
+}
+
+func Test_VerifyErr(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+	signature := signer.Sign("message")
+
+	assert.NoError(t, signer.VerifyErr("message", signature))
+	assert.NoError(t, verifier.VerifyErr("message", signature))
+	assert.ErrorIs(t, signer.VerifyErr("other", signature), ErrBadSignature)
+	assert.ErrorIs(t, verifier.VerifyErr("other", signature), ErrBadSignature)
+	assert.ErrorIs(t, signer.VerifyErr("message", "!"), ErrInvalidBase64)
+	assert.ErrorIs(t, verifier.VerifyErr("message", "AAAA"), ErrInvalidSignatureSize)
+}
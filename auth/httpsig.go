@@ -0,0 +1,480 @@
+package auth
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedramktb/go-base-lib/taggederror"
+)
+
+var (
+	ErrHTTPSignatureMissing = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("missing request signature"), "HTTP_SIGNATURE_MISSING"),
+	)
+	ErrHTTPSignatureMalformed = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("malformed request signature"), "HTTP_SIGNATURE_MALFORMED"),
+	)
+	ErrHTTPSignatureBadSignature = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("invalid request signature"), "HTTP_SIGNATURE_BAD_SIGNATURE"),
+	)
+	ErrHTTPSignatureExpired = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("request signature expired"), "HTTP_SIGNATURE_EXPIRED"),
+	)
+	ErrHTTPSignatureReplayed = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("request signature replayed"), "HTTP_SIGNATURE_REPLAYED"),
+	)
+	ErrHTTPSignatureDigestMismatch = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("request body does not match its digest"), "HTTP_SIGNATURE_DIGEST_MISMATCH"),
+	)
+)
+
+// defaultMaxBodySize is the maximum size of request bodies read by VerifyRequest without HTTPVerification.MaxBodySize
+const defaultMaxBodySize = 10 << 20
+
+// httpSignatureLabel is the label of the signature in the Signature-Input and Signature headers
+const httpSignatureLabel = "sig1"
+
+// SignRequest signs a request following RFC 9421 (HTTP Message Signatures) with the ed25519 algorithm.
+// The signature covers the method, authority, path, query, the Content-Digest (sha-256) of the body and the given headers.
+// The creation time and a random nonce are part of the signature parameters, the key ID is ED25519Signer.KeyID.
+// The body is read and replaced to compute its digest.
+func (s *ED25519Signer) SignRequest(r *http.Request, headers ...string) error {
+	components := []string{"@method", "@authority", "@path"}
+	if r.URL.RawQuery != "" {
+		components = append(components, "@query")
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error reading request body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.Header.Set("Content-Digest", contentDigest(body))
+		components = append(components, "content-digest")
+	}
+
+	for _, header := range headers {
+		header = strings.ToLower(header)
+		if !slices.Contains(components, header) {
+			components = append(components, header)
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	params := httpSignatureParams{
+		components: components,
+		created:    time.Now().Unix(),
+		keyID:      s.KeyID(),
+		nonce:      base64.RawURLEncoding.EncodeToString(nonce),
+	}
+
+	base, err := signatureBase(r, params)
+	if err != nil {
+		return err
+	}
+	signature, err := s.sign([]byte(base))
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Signature-Input", httpSignatureLabel+"="+params.String())
+	r.Header.Set("Signature", httpSignatureLabel+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// SigningTransport is a http.RoundTripper that signs all requests with ED25519Signer.SignRequest
+type SigningTransport struct {
+	signer  *ED25519Signer
+	headers []string
+	base    http.RoundTripper
+}
+
+// NewSigningTransport creates a new SigningTransport that signs the given headers in addition to the default components
+// and sends the requests with base (http.DefaultTransport if nil)
+func NewSigningTransport(signer *ED25519Signer, base http.RoundTripper, headers ...string) *SigningTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &SigningTransport{
+		signer:  signer,
+		headers: headers,
+		base:    base,
+	}
+}
+
+func (t *SigningTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request
+	signed := r.Clone(r.Context())
+	if err := t.signer.SignRequest(signed, t.headers...); err != nil {
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(signed)
+}
+
+// HTTPVerification configures the verification of signed requests
+type HTTPVerification struct {
+	// Headers are the headers that have to be covered by the signature in addition to the method, authority and path
+	Headers []string
+	// MaxAge is the maximum age of a signature (5 minutes by default)
+	MaxAge time.Duration
+	// ClockSkew is the tolerance for signatures created in the future
+	ClockSkew time.Duration
+	// Nonces stores the nonces of verified signatures to reject replayed requests, nil disables replay protection
+	Nonces NonceStore
+	// Now returns the current time (time.Now by default)
+	Now func() time.Time
+	// MaxBodySize is the maximum size of the body that is read to verify its digest (10 MiB by default),
+	// a negative value disables the limit
+	MaxBodySize int64
+}
+
+// VerifyRequest verifies the signature of a request signed by ED25519Signer.SignRequest.
+// The body is read and replaced to compare it with the Content-Digest, which has to be signed if there is a body.
+// Bodies larger than HTTPVerification.MaxBodySize are rejected with ErrHTTPSignatureMalformed.
+// The returned errors are ErrHTTPSignatureMissing, ErrHTTPSignatureMalformed, ErrHTTPSignatureBadSignature,
+// ErrHTTPSignatureExpired, ErrHTTPSignatureReplayed or ErrHTTPSignatureDigestMismatch.
+func (v *ED25519Verifier) VerifyRequest(r *http.Request, verification HTTPVerification) error {
+	input, signatureHeader := r.Header.Get("Signature-Input"), r.Header.Get("Signature")
+	if input == "" || signatureHeader == "" {
+		return ErrHTTPSignatureMissing
+	}
+
+	params, err := parseHTTPSignatureParams(input)
+	if err != nil {
+		return ErrHTTPSignatureMalformed.Wrap(err)
+	}
+	encodedSignature, ok := dictionaryMember(signatureHeader, httpSignatureLabel)
+	if !ok || len(encodedSignature) < 2 || encodedSignature[0] != ':' || encodedSignature[len(encodedSignature)-1] != ':' {
+		return ErrHTTPSignatureMalformed
+	}
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature[1 : len(encodedSignature)-1])
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return ErrHTTPSignatureMalformed
+	}
+
+	required := []string{"@method", "@authority", "@path"}
+	if r.URL.RawQuery != "" {
+		required = append(required, "@query")
+	}
+	for _, header := range verification.Headers {
+		required = append(required, strings.ToLower(header))
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		reader := r.Body
+		maxBodySize := verification.MaxBodySize
+		if maxBodySize == 0 {
+			maxBodySize = defaultMaxBodySize
+		}
+		if maxBodySize > 0 {
+			reader = http.MaxBytesReader(nil, r.Body, maxBodySize)
+		}
+		body, err = io.ReadAll(reader)
+		_ = r.Body.Close()
+		if err != nil {
+			return ErrHTTPSignatureMalformed.Wrap(err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if len(body) > 0 {
+		required = append(required, "content-digest")
+	}
+	for _, component := range required {
+		if !slices.Contains(params.components, component) {
+			return ErrHTTPSignatureMalformed.Wrap(fmt.Errorf("component %s is not signed", component))
+		}
+	}
+	if slices.Contains(params.components, "content-digest") {
+		expected := contentDigest(body)
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Content-Digest")), []byte(expected)) != 1 {
+			return ErrHTTPSignatureDigestMismatch
+		}
+	}
+
+	base, err := signatureBase(r, params)
+	if err != nil {
+		return ErrHTTPSignatureMalformed.Wrap(err)
+	}
+	if !v.verify(params.keyID, []byte(base), signature) {
+		return ErrHTTPSignatureBadSignature
+	}
+
+	now := time.Now()
+	if verification.Now != nil {
+		now = verification.Now()
+	}
+	maxAge := verification.MaxAge
+	if maxAge == 0 {
+		maxAge = 5 * time.Minute
+	}
+	created := time.Unix(params.created, 0)
+	if !now.Before(created.Add(maxAge)) {
+		return ErrHTTPSignatureExpired
+	}
+	if now.Add(verification.ClockSkew).Before(created) {
+		return ErrHTTPSignatureExpired
+	}
+
+	if verification.Nonces != nil {
+		if params.nonce == "" {
+			return ErrHTTPSignatureMalformed.Wrap(errors.New("missing nonce"))
+		}
+		if !verification.Nonces.Use(params.keyID+":"+params.nonce, created.Add(maxAge+verification.ClockSkew)) {
+			return ErrHTTPSignatureReplayed
+		}
+	}
+
+	return nil
+}
+
+// Middleware returns a http middleware that verifies the signature of all requests with VerifyRequest.
+// Failures are handled by taggederror.Handler.
+func (v *ED25519Verifier) Middleware(verification HTTPVerification) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := v.VerifyRequest(r, verification); err != nil {
+				taggederror.Handler(err, w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NonceStore remembers the nonces of verified request signatures
+type NonceStore interface {
+	// Use marks the nonce as used until expiresAt and returns false if it was already used
+	Use(nonce string, expiresAt time.Time) bool
+}
+
+// MemoryNonceStore is an in-memory NonceStore for a single instance.
+// Expired nonces are removed in the order of their expiry, so Use does not scan all nonces.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	expiry nonceHeap
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: map[string]time.Time{}}
+}
+
+func (s *MemoryNonceStore) Use(nonce string, expiresAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for len(s.expiry) > 0 && !now.Before(s.expiry[0].expiresAt) {
+		delete(s.nonces, heap.Pop(&s.expiry).(nonceEntry).nonce)
+	}
+
+	if _, ok := s.nonces[nonce]; ok {
+		return false
+	}
+	s.nonces[nonce] = expiresAt
+	heap.Push(&s.expiry, nonceEntry{nonce: nonce, expiresAt: expiresAt})
+	return true
+}
+
+type nonceEntry struct {
+	nonce     string
+	expiresAt time.Time
+}
+
+// nonceHeap is a heap.Interface of nonces ordered by their expiry
+type nonceHeap []nonceEntry
+
+func (h nonceHeap) Len() int           { return len(h) }
+func (h nonceHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h nonceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *nonceHeap) Push(x any)        { *h = append(*h, x.(nonceEntry)) }
+func (h *nonceHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// httpSignatureParams are the signature parameters of the Signature-Input header
+type httpSignatureParams struct {
+	components []string
+	created    int64
+	keyID      string
+	nonce      string
+	// raw is the serialized form of parsed parameters, which is signed as is
+	raw string
+}
+
+func (p httpSignatureParams) String() string {
+	if p.raw != "" {
+		return p.raw
+	}
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i, component := range p.components {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(strconv.Quote(component))
+	}
+	sb.WriteString(");created=")
+	sb.WriteString(strconv.FormatInt(p.created, 10))
+	sb.WriteString(";keyid=")
+	sb.WriteString(strconv.Quote(p.keyID))
+	if p.nonce != "" {
+		sb.WriteString(";nonce=")
+		sb.WriteString(strconv.Quote(p.nonce))
+	}
+	sb.WriteString(`;alg="ed25519"`)
+	return sb.String()
+}
+
+func parseHTTPSignatureParams(input string) (httpSignatureParams, error) {
+	var params httpSignatureParams
+
+	value, ok := dictionaryMember(input, httpSignatureLabel)
+	if !ok {
+		return params, fmt.Errorf("missing signature %s", httpSignatureLabel)
+	}
+	params.raw = value
+	if !strings.HasPrefix(value, "(") {
+		return params, errors.New("invalid component list")
+	}
+	list, rest, ok := strings.Cut(value[1:], ")")
+	if !ok {
+		return params, errors.New("invalid component list")
+	}
+	for _, item := range strings.Fields(list) {
+		component, err := strconv.Unquote(item)
+		if err != nil {
+			return params, fmt.Errorf("invalid component %s", item)
+		}
+		params.components = append(params.components, component)
+	}
+
+	hasCreated := false
+	for _, param := range strings.Split(rest, ";") {
+		if param == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(param, "=")
+		switch key {
+		case "created":
+			created, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return params, errors.New("invalid created parameter")
+			}
+			params.created = created
+			hasCreated = true
+		case "keyid", "nonce", "alg":
+			unquoted, err := strconv.Unquote(val)
+			if err != nil {
+				return params, fmt.Errorf("invalid %s parameter", key)
+			}
+			switch key {
+			case "keyid":
+				params.keyID = unquoted
+			case "nonce":
+				params.nonce = unquoted
+			case "alg":
+				if unquoted != "ed25519" {
+					return params, fmt.Errorf("unsupported algorithm %s", unquoted)
+				}
+			}
+		}
+	}
+	if !hasCreated {
+		return params, errors.New("missing created parameter")
+	}
+
+	return params, nil
+}
+
+// dictionaryMember returns the value of a member of a structured field dictionary (e.g. sig1=... of the Signature header)
+func dictionaryMember(header, key string) (string, bool) {
+	for _, member := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if ok && name == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// signatureBase builds the signature base of RFC 9421 for the covered components of the request
+func signatureBase(r *http.Request, params httpSignatureParams) (string, error) {
+	var sb strings.Builder
+	for _, component := range params.components {
+		var value string
+		switch component {
+		case "@method":
+			value = r.Method
+		case "@authority":
+			value = r.Host
+			if value == "" {
+				value = r.URL.Host
+			}
+			value = strings.ToLower(value)
+		case "@path":
+			value = r.URL.EscapedPath()
+			if value == "" {
+				value = "/"
+			}
+		case "@query":
+			value = "?" + r.URL.RawQuery
+		default:
+			if strings.HasPrefix(component, "@") {
+				return "", fmt.Errorf("unsupported component %s", component)
+			}
+			values := r.Header.Values(component)
+			if len(values) == 0 {
+				return "", fmt.Errorf("missing header %s", component)
+			}
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.TrimSpace(v)
+			}
+			value = strings.Join(trimmed, ", ")
+		}
+		sb.WriteString(strconv.Quote(component))
+		sb.WriteString(": ")
+		sb.WriteString(value)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(`"@signature-params": `)
+	sb.WriteString(params.String())
+	return sb.String(), nil
+}
+
+// contentDigest returns the Content-Digest header value of RFC 9530 for the body
+func contentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HTTPSignature(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	var received string
+	server := httptest.NewServer(verifier.Middleware(HTTPVerification{
+		Headers: []string{"X-Request-ID"},
+		Nonces:  NewMemoryNonceStore(),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	})))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewSigningTransport(signer, nil, "X-Request-ID")}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/path?a=b", strings.NewReader("body"))
+	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "1")
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "body", received)
+	assert.Empty(t, req.Header.Get("Signature"), "the original request must not be modified")
+
+	// Unsigned requests are rejected by taggederror.Handler
+	res, err = http.Post(server.URL+"/path", "text/plain", strings.NewReader("body"))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Contains(t, string(body), "HTTP_SIGNATURE_MISSING")
+}
+
+func Test_VerifyRequest(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	newSignedRequest := func(t *testing.T, headers ...string) *http.Request {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/path?a=b", strings.NewReader("body"))
+		req.Header.Set("X-Request-ID", "1")
+		require.NoError(t, signer.SignRequest(req, headers...))
+		return req
+	}
+
+	tests := []struct {
+		name         string
+		modify       func(r *http.Request)
+		verification HTTPVerification
+		err          error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:   "missing",
+			modify: func(r *http.Request) { r.Header.Del("Signature") },
+			err:    ErrHTTPSignatureMissing,
+		},
+		{
+			name:   "malformed",
+			modify: func(r *http.Request) { r.Header.Set("Signature", "sig1=abc") },
+			err:    ErrHTTPSignatureMalformed,
+		},
+		{
+			name:   "other method",
+			modify: func(r *http.Request) { r.Method = http.MethodPut },
+			err:    ErrHTTPSignatureBadSignature,
+		},
+		{
+			name:   "other path",
+			modify: func(r *http.Request) { r.URL.Path = "/other" },
+			err:    ErrHTTPSignatureBadSignature,
+		},
+		{
+			name:   "other query",
+			modify: func(r *http.Request) { r.URL.RawQuery = "a=c" },
+			err:    ErrHTTPSignatureBadSignature,
+		},
+		{
+			name:   "other body",
+			modify: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader("other")) },
+			err:    ErrHTTPSignatureDigestMismatch,
+		},
+		{
+			name:         "unsigned required header",
+			verification: HTTPVerification{Headers: []string{"X-Request-ID"}},
+			err:          ErrHTTPSignatureMalformed,
+		},
+		{
+			name:         "expired",
+			verification: HTTPVerification{Now: func() time.Time { return time.Now().Add(10 * time.Minute) }},
+			err:          ErrHTTPSignatureExpired,
+		},
+		{
+			name:         "body too large",
+			verification: HTTPVerification{MaxBodySize: 2},
+			err:          ErrHTTPSignatureMalformed,
+		},
+		{
+			name:         "unlimited body",
+			verification: HTTPVerification{MaxBodySize: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newSignedRequest(t)
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+			err := verifier.VerifyRequest(req, tt.verification)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+
+	t.Run("signed header", func(t *testing.T) {
+		req := newSignedRequest(t, "X-Request-ID")
+		assert.NoError(t, verifier.VerifyRequest(req, HTTPVerification{Headers: []string{"x-request-id"}}))
+		req.Header.Set("X-Request-ID", "2")
+		assert.ErrorIs(t, verifier.VerifyRequest(req, HTTPVerification{}), ErrHTTPSignatureBadSignature)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		verification := HTTPVerification{Nonces: NewMemoryNonceStore()}
+		req := newSignedRequest(t)
+		assert.NoError(t, verifier.VerifyRequest(req, verification))
+		assert.ErrorIs(t, verifier.VerifyRequest(req, verification), ErrHTTPSignatureReplayed)
+	})
+}
+
+func Test_MemoryNonceStore(t *testing.T) {
+	store := NewMemoryNonceStore()
+	now := time.Now()
+
+	assert.True(t, store.Use("a", now.Add(time.Hour)))
+	assert.True(t, store.Use("b", now.Add(-time.Second)))
+	assert.False(t, store.Use("a", now.Add(time.Hour)))
+
+	// Expired nonces are removed and can be used again
+	assert.True(t, store.Use("b", now.Add(time.Hour)))
+	assert.Len(t, store.nonces, 2)
+	assert.Len(t, store.expiry, 2)
+}
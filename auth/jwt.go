@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JWTClaims are the claims of a RFC 7519 JWT, the registered claims have their own fields and all others are in Extra
+type JWTClaims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+	Extra     map[string]any
+}
+
+func (c JWTClaims) MarshalJSON() ([]byte, error) {
+	claims := make(map[string]any, len(c.Extra)+7)
+	for k, v := range c.Extra {
+		claims[k] = v
+	}
+	setIfNotEmpty(claims, "iss", c.Issuer)
+	setIfNotEmpty(claims, "sub", c.Subject)
+	setIfNotEmpty(claims, "jti", c.ID)
+	switch len(c.Audience) {
+	case 0:
+	case 1:
+		claims["aud"] = c.Audience[0]
+	default:
+		claims["aud"] = c.Audience
+	}
+	setNumericDate(claims, "exp", c.ExpiresAt)
+	setNumericDate(claims, "nbf", c.NotBefore)
+	setNumericDate(claims, "iat", c.IssuedAt)
+	return json.Marshal(claims)
+}
+
+func (c *JWTClaims) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var claims map[string]any
+	if err := decoder.Decode(&claims); err != nil {
+		return err
+	}
+
+	var registered struct {
+		Issuer    string          `json:"iss"`
+		Subject   string          `json:"sub"`
+		Audience  json.RawMessage `json:"aud"`
+		ExpiresAt json.Number     `json:"exp"`
+		NotBefore json.Number     `json:"nbf"`
+		IssuedAt  json.Number     `json:"iat"`
+		ID        string          `json:"jti"`
+	}
+	if err := json.Unmarshal(data, &registered); err != nil {
+		return err
+	}
+
+	*c = JWTClaims{
+		Issuer:  registered.Issuer,
+		Subject: registered.Subject,
+		ID:      registered.ID,
+	}
+	if len(registered.Audience) > 0 {
+		if registered.Audience[0] == '"' {
+			c.Audience = make([]string, 1)
+			if err := json.Unmarshal(registered.Audience, &c.Audience[0]); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(registered.Audience, &c.Audience); err != nil {
+			return err
+		}
+	}
+	var err error
+	if c.ExpiresAt, err = numericDate(registered.ExpiresAt); err != nil {
+		return err
+	}
+	if c.NotBefore, err = numericDate(registered.NotBefore); err != nil {
+		return err
+	}
+	if c.IssuedAt, err = numericDate(registered.IssuedAt); err != nil {
+		return err
+	}
+
+	for _, k := range []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"} {
+		delete(claims, k)
+	}
+	if len(claims) > 0 {
+		c.Extra = claims
+	}
+	return nil
+}
+
+func setIfNotEmpty(claims map[string]any, key, value string) {
+	if value != "" {
+		claims[key] = value
+	}
+}
+
+func setNumericDate(claims map[string]any, key string, t time.Time) {
+	if !t.IsZero() {
+		claims[key] = t.Unix()
+	}
+}
+
+func numericDate(n json.Number) (time.Time, error) {
+	if n == "" {
+		return time.Time{}, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(f), 0), nil
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// IssueJWT issues a RFC 7519 JWT signed with EdDSA that expires after ttl.
+// IssuedAt and ExpiresAt are set by the signer and the key ID is set in the header.
+func (s *ED25519Signer) IssueJWT(claims JWTClaims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.IssuedAt = now
+	claims.ExpiresAt = now.Add(ttl)
+
+	header, err := json.Marshal(jwtHeader{Algorithm: "EdDSA", Type: "JWT", KeyID: s.KeyID()})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	message := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := s.sign([]byte(message))
+	if err != nil {
+		return "", err
+	}
+	return message + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyJWT verifies the signature of a JWT signed with EdDSA and validates its claims.
+// The JWT is verified with the key of the kid header or with all valid keys if the kid is unknown.
+// The returned errors are the same as in VerifyToken, the audience is valid if it is one of the JWT's audiences.
+func (v *ED25519Verifier) VerifyJWT(token string, validation TokenValidation) (*JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	encodedHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(encodedHeader, &header); err != nil {
+		return nil, ErrTokenMalformed.Wrap(err)
+	}
+	// Only EdDSA is accepted to prevent algorithm confusion (e.g. "none")
+	if header.Algorithm != "EdDSA" {
+		return nil, ErrTokenMalformed
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, ErrTokenMalformed
+	}
+	if !v.verify(header.KeyID, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrTokenBadSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	claims := &JWTClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrTokenMalformed.Wrap(err)
+	}
+
+	if err := validation.validate(claims.Issuer, claims.Audience, claims.NotBefore, claims.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// JWK is a RFC 7517 JSON Web Key of an Ed25519 public key (RFC 8037)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+func newJWK(publicKey ed25519.PublicKey) JWK {
+	return JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(publicKey),
+		KeyID:     keyID(publicKey),
+		Algorithm: "EdDSA",
+		Use:       "sig",
+	}
+}
+
+// JWK returns the JSON Web Key of the master private key's public key
+func (s *ED25519Signer) JWK() JWK {
+	return newJWK(s.publicKey)
+}
+
+// JWK returns the JSON Web Key of the active key, see JWKS for all keys
+func (v *ED25519Verifier) JWK() JWK {
+	return newJWK(v.publicKey())
+}
+
+// JWKS is a RFC 7517 JSON Web Key Set, it serves itself as an http.Handler (e.g. at /.well-known/jwks.json).
+// Use ED25519Verifier.JWKSHandler to serve the changing keys of a key ring.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWKS creates a JWKS with the public keys of the signers
+func NewJWKS(signers ...*ED25519Signer) JWKS {
+	jwks := JWKS{Keys: make([]JWK, len(signers))}
+	for i, signer := range signers {
+		jwks.Keys[i] = signer.JWK()
+	}
+	return jwks
+}
+
+func (jwks JWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(jwks)
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JWT(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	token, err := signer.IssueJWT(JWTClaims{
+		Issuer:   "issuer",
+		Subject:  "subject",
+		Audience: []string{"a", "b"},
+		Extra:    map[string]any{"role": "admin"},
+	}, time.Minute)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"alg":"EdDSA","typ":"JWT","kid":"`+signer.KeyID()+`"}`, string(header))
+
+	claims, err := verifier.VerifyJWT(token, TokenValidation{Issuer: "issuer", Audience: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "subject", claims.Subject)
+	assert.Equal(t, []string{"a", "b"}, claims.Audience)
+	assert.Equal(t, map[string]any{"role": "admin"}, claims.Extra)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), claims.ExpiresAt, 2*time.Second)
+
+	_, err = verifier.VerifyJWT(token, TokenValidation{Audience: "c"})
+	assert.ErrorIs(t, err, ErrTokenInvalidAudience)
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "." + parts[2]
+	_, err = verifier.VerifyJWT(none, TokenValidation{})
+	assert.ErrorIs(t, err, ErrTokenMalformed)
+
+	_, err = verifier.VerifyJWT(parts[0]+"."+parts[1]+"."+base64.RawURLEncoding.EncodeToString(make([]byte, ed25519.SignatureSize)), TokenValidation{})
+	assert.ErrorIs(t, err, ErrTokenBadSignature)
+}
+
+func Test_JWKS(t *testing.T) {
+	signer := newTestSigner(t)
+
+	rec := httptest.NewRecorder()
+	NewJWKS(signer).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	assert.Equal(t, "application/jwk-set+json", rec.Header().Get("Content-Type"))
+
+	var jwks JWKS
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Curve)
+	assert.Equal(t, signer.KeyID(), jwks.Keys[0].KeyID)
+
+	publicKey, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].X)
+	require.NoError(t, err)
+	assert.Equal(t, signer.PublicKey(), base64.StdEncoding.EncodeToString(publicKey))
+}
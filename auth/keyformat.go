@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// NewED25519SignerFromPEM creates a new ED25519Signer from a PKCS#8 PEM ("PRIVATE KEY") or
+// OpenSSH ("OPENSSH PRIVATE KEY") private key. The passphrase is only used for encrypted OpenSSH keys
+// and ignored for unencrypted keys.
+func NewED25519SignerFromPEM(pemBytes, passphrase []byte) (*ED25519Signer, error) {
+	raw, err := ssh.ParseRawPrivateKey(pemBytes)
+	var passphraseMissing *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseMissing) && len(passphrase) > 0 {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase(pemBytes, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+
+	switch key := raw.(type) {
+	case ed25519.PrivateKey:
+		return NewED25519SignerFromSigner(key)
+	case *ed25519.PrivateKey:
+		return NewED25519SignerFromSigner(*key)
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", raw)
+	}
+}
+
+// NewED25519VerifierFromPEM creates a new ED25519Verifier from a PKIX PEM ("PUBLIC KEY") public key
+func NewED25519VerifierFromPEM(pemBytes []byte) (*ED25519Verifier, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("no PEM public key found")
+	}
+	raw, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key: %w", err)
+	}
+	key, ok := raw.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type: %T", raw)
+	}
+
+	v := &ED25519Verifier{}
+	v.setMasterPublicKey(key)
+	return v, nil
+}
+
+// NewED25519VerifierFromAuthorizedKey creates a new ED25519Verifier from an OpenSSH authorized_keys line
+// (e.g. "ssh-ed25519 AAAA... comment", the content of a .pub file)
+func NewED25519VerifierFromAuthorizedKey(line []byte) (*ED25519Verifier, error) {
+	sshKey, _, _, _, err := ssh.ParseAuthorizedKey(line)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing authorized key: %w", err)
+	}
+	cryptoKey, ok := sshKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type: %s", sshKey.Type())
+	}
+	key, ok := cryptoKey.CryptoPublicKey().(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type: %s", sshKey.Type())
+	}
+
+	v := &ED25519Verifier{}
+	v.setMasterPublicKey(key)
+	return v, nil
+}
+
+// MarshalPEM returns the master private key as PKCS#8 PEM ("PRIVATE KEY")
+// or ErrPrivateKeyUnavailable for external signers
+func (s *ED25519Signer) MarshalPEM() ([]byte, error) {
+	privateKey, err := s.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// MarshalOpenSSH returns the master private key as OpenSSH PEM ("OPENSSH PRIVATE KEY"),
+// encrypted if a passphrase is given, or ErrPrivateKeyUnavailable for external signers
+func (s *ED25519Signer) MarshalOpenSSH(comment string, passphrase []byte) ([]byte, error) {
+	privateKey, err := s.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	var block *pem.Block
+	if len(passphrase) > 0 {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(privateKey, comment, passphrase)
+	} else {
+		block, err = ssh.MarshalPrivateKey(privateKey, comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling private key: %w", err)
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// MarshalPublicKeyPEM returns the master private key's public key as PKIX PEM ("PUBLIC KEY")
+func (s *ED25519Signer) MarshalPublicKeyPEM() ([]byte, error) {
+	return marshalPublicKeyPEM(s.publicKey)
+}
+
+// AuthorizedKey returns the master private key's public key as an OpenSSH authorized_keys line without a newline
+func (s *ED25519Signer) AuthorizedKey() string {
+	return strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(s.sshSigner.PublicKey())), "\n")
+}
+
+// MarshalPEM returns the active public key as PKIX PEM ("PUBLIC KEY")
+func (v *ED25519Verifier) MarshalPEM() ([]byte, error) {
+	publicKey := v.publicKey()
+	if publicKey == nil {
+		return nil, errors.New("no active key")
+	}
+	return marshalPublicKeyPEM(publicKey)
+}
+
+// AuthorizedKey returns the active public key as an OpenSSH authorized_keys line without a newline
+func (v *ED25519Verifier) AuthorizedKey() (string, error) {
+	publicKey := v.publicKey()
+	if publicKey == nil {
+		return "", errors.New("no active key")
+	}
+	sshKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("error creating ssh public key: %w", err)
+	}
+	return strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshKey)), "\n"), nil
+}
+
+func marshalPublicKeyPEM(publicKey ed25519.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
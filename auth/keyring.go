@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// KeyState is the state of a key in the key ring of an ED25519Verifier
+type KeyState int
+
+const (
+	// KeyStateActive is the key currently used for signing, a ring has at most one active key
+	KeyStateActive KeyState = iota
+	// KeyStateRetired is a key that is only used for verification (until it expires)
+	KeyStateRetired
+)
+
+func (s KeyState) String() string {
+	switch s {
+	case KeyStateActive:
+		return "active"
+	case KeyStateRetired:
+		return "retired"
+	default:
+		return fmt.Sprintf("KeyState(%d)", int(s))
+	}
+}
+
+type ringKey struct {
+	publicKey ed25519.PublicKey
+	state     KeyState
+	// expiresAt is the time after which the key is not used anymore, zero if it does not expire
+	expiresAt time.Time
+}
+
+func (k *ringKey) valid(now time.Time) bool {
+	return k.expiresAt.IsZero() || now.Before(k.expiresAt)
+}
+
+// AddKey adds a base64 encoded public key to the key ring and returns its key ID.
+// Adding an active key retires the previously active key (keeping its expiry).
+// A zero expiresAt means the key does not expire.
+func (v *ED25519Verifier) AddKey(publicKey string, state KeyState, expiresAt time.Time) (string, error) {
+	pubKey, _ := base64.StdEncoding.DecodeString(publicKey)
+	if len(pubKey) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("invalid public key size")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.addKey(pubKey, state, expiresAt), nil
+}
+
+// addKey adds the public key to the key ring and returns its key ID, v.mu must be held
+func (v *ED25519Verifier) addKey(pubKey ed25519.PublicKey, state KeyState, expiresAt time.Time) string {
+	kid := keyID(pubKey)
+
+	// Expired keys are removed when the ring changes
+	now := time.Now()
+	for kid, key := range v.keys {
+		if !key.valid(now) {
+			delete(v.keys, kid)
+		}
+	}
+
+	if v.keys == nil {
+		v.keys = map[string]*ringKey{}
+	}
+	if state == KeyStateActive {
+		if prev, ok := v.keys[v.active]; ok && v.active != kid {
+			prev.state = KeyStateRetired
+		}
+		v.active = kid
+	} else if v.active == kid {
+		v.active = ""
+	}
+	v.keys[kid] = &ringKey{
+		publicKey: pubKey,
+		state:     state,
+		expiresAt: expiresAt,
+	}
+
+	return kid
+}
+
+// Rotate adds a new active key and retires the previously active key, which stays valid for the overlap
+// so signatures of the previous key can still be verified during the rotation window.
+func (v *ED25519Verifier) Rotate(publicKey string, overlap time.Duration) (string, error) {
+	pubKey, _ := base64.StdEncoding.DecodeString(publicKey)
+	if len(pubKey) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("invalid public key size")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	prev, ok := v.keys[v.active]
+	kid := v.addKey(pubKey, KeyStateActive, time.Time{})
+	if ok && prev != v.keys[kid] {
+		prev.expiresAt = time.Now().Add(overlap)
+	}
+	return kid, nil
+}
+
+// RetireKey retires a key so it is only used for verification until expiresAt (or forever if it is zero)
+func (v *ED25519Verifier) RetireKey(kid string, expiresAt time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return fmt.Errorf("unknown key id: %s", kid)
+	}
+	key.state = KeyStateRetired
+	key.expiresAt = expiresAt
+	if v.active == kid {
+		v.active = ""
+	}
+	return nil
+}
+
+// RemoveKey removes a key from the key ring
+func (v *ED25519Verifier) RemoveKey(kid string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.keys, kid)
+	if v.active == kid {
+		v.active = ""
+	}
+}
+
+// KeyIDs returns the key IDs of all valid keys, the active key first
+func (v *ED25519Verifier) KeyIDs() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.validKeyIDs()
+}
+
+// validKeyIDs returns the key IDs of all valid keys with the active key first, v.mu must be held
+func (v *ED25519Verifier) validKeyIDs() []string {
+	now := time.Now()
+	kids := make([]string, 0, len(v.keys))
+	for kid, key := range v.keys {
+		if kid != v.active && key.valid(now) {
+			kids = append(kids, kid)
+		}
+	}
+	slices.Sort(kids)
+	if key, ok := v.keys[v.active]; ok && key.valid(now) {
+		kids = append([]string{v.active}, kids...)
+	}
+	return kids
+}
+
+// KeyState returns the state of a key and false if the key is unknown or expired
+func (v *ED25519Verifier) KeyState(kid string) (KeyState, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	key, ok := v.keys[kid]
+	if !ok || !key.valid(time.Now()) {
+		return 0, false
+	}
+	return key.state, true
+}
+
+// candidates returns the public key of kid if it is known and valid,
+// otherwise all valid public keys with the active key first
+func (v *ED25519Verifier) candidates(kid string) []ed25519.PublicKey {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if key, ok := v.keys[kid]; ok && key.valid(time.Now()) {
+		return []ed25519.PublicKey{key.publicKey}
+	}
+
+	kids := v.validKeyIDs()
+	publicKeys := make([]ed25519.PublicKey, len(kids))
+	for i, kid := range kids {
+		publicKeys[i] = v.keys[kid].publicKey
+	}
+	return publicKeys
+}
+
+// publicKey returns the active public key or nil if there is none
+func (v *ED25519Verifier) publicKey() ed25519.PublicKey {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.keys[v.active]; ok {
+		return key.publicKey
+	}
+	return nil
+}
+
+// KeyID returns the key ID of the active key or an empty string if there is none
+func (v *ED25519Verifier) KeyID() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.active
+}
+
+// verify verifies the message with the key of kid or with all valid keys if kid is unknown
+func (v *ED25519Verifier) verify(kid string, message, signature []byte) bool {
+	for _, publicKey := range v.candidates(kid) {
+		if ed25519.Verify(publicKey, message, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// JWKS returns the JSON Web Key Set of all valid keys (e.g. to publish the keys of a rotation window).
+// The set is a snapshot, use JWKSHandler to serve the current keys.
+func (v *ED25519Verifier) JWKS() JWKS {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	kids := v.validKeyIDs()
+	jwks := JWKS{Keys: make([]JWK, len(kids))}
+	for i, kid := range kids {
+		jwks.Keys[i] = newJWK(v.keys[kid].publicKey)
+	}
+	return jwks
+}
+
+// JWKSHandler returns an http.Handler that serves the JWKS of the current keys (e.g. at /.well-known/jwks.json),
+// so added, rotated and retired keys are published without replacing the handler
+func (v *ED25519Verifier) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v.JWKS().ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_KeyRing_Rotate(t *testing.T) {
+	oldSigner := newTestSigner(t)
+	newSigner := newTestSigner(t)
+	verifier, err := NewED25519Verifier(oldSigner.PublicKey())
+	require.NoError(t, err)
+
+	oldToken, err := oldSigner.IssueToken(TokenClaims{Subject: "old"}, time.Minute)
+	require.NoError(t, err)
+	oldJWT, err := oldSigner.IssueJWT(JWTClaims{Subject: "old"}, time.Minute)
+	require.NoError(t, err)
+
+	kid, err := verifier.Rotate(newSigner.PublicKey(), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, newSigner.KeyID(), kid)
+	assert.Equal(t, newSigner.KeyID(), verifier.KeyID())
+	assert.Equal(t, []string{newSigner.KeyID(), oldSigner.KeyID()}, verifier.KeyIDs())
+
+	state, ok := verifier.KeyState(oldSigner.KeyID())
+	require.True(t, ok)
+	assert.Equal(t, KeyStateRetired, state)
+
+	// Signatures of both keys are valid during the overlap
+	newToken, err := newSigner.IssueToken(TokenClaims{Subject: "new"}, time.Minute)
+	require.NoError(t, err)
+	claims, err := verifier.VerifyToken(newToken, TokenValidation{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", claims.Subject)
+	claims, err = verifier.VerifyToken(oldToken, TokenValidation{})
+	require.NoError(t, err)
+	assert.Equal(t, "old", claims.Subject)
+	jwtClaims, err := verifier.VerifyJWT(oldJWT, TokenValidation{})
+	require.NoError(t, err)
+	assert.Equal(t, "old", jwtClaims.Subject)
+	assert.True(t, verifier.Verify("message", oldSigner.Sign("message")))
+	assert.True(t, verifier.Verify("message", newSigner.Sign("message")))
+
+	jwks := verifier.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, newSigner.KeyID(), jwks.Keys[0].KeyID)
+	assert.Equal(t, oldSigner.KeyID(), jwks.Keys[1].KeyID)
+
+	// The old key is not valid after it is removed
+	verifier.RemoveKey(oldSigner.KeyID())
+	_, err = verifier.VerifyToken(oldToken, TokenValidation{})
+	assert.ErrorIs(t, err, ErrTokenBadSignature)
+	assert.False(t, verifier.Verify("message", oldSigner.Sign("message")))
+	assert.Len(t, verifier.JWKS().Keys, 1)
+}
+
+func Test_KeyRing_Expiry(t *testing.T) {
+	oldSigner := newTestSigner(t)
+	newSigner := newTestSigner(t)
+	verifier, err := NewED25519Verifier(oldSigner.PublicKey())
+	require.NoError(t, err)
+
+	oldToken, err := oldSigner.IssueToken(TokenClaims{}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = verifier.Rotate(newSigner.PublicKey(), -time.Second)
+	require.NoError(t, err)
+
+	_, err = verifier.VerifyToken(oldToken, TokenValidation{})
+	assert.ErrorIs(t, err, ErrTokenBadSignature)
+	_, ok := verifier.KeyState(oldSigner.KeyID())
+	assert.False(t, ok)
+	assert.Equal(t, []string{newSigner.KeyID()}, verifier.KeyIDs())
+}
+
+func Test_KeyRing_ConcurrentRotate(t *testing.T) {
+	verifier, err := NewED25519Verifier(newTestSigner(t).PublicKey())
+	require.NoError(t, err)
+
+	signers := make([]*ED25519Signer, 8)
+	for i := range signers {
+		signers[i] = newTestSigner(t)
+	}
+
+	var wg sync.WaitGroup
+	for _, signer := range signers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.Rotate(signer.PublicKey(), time.Hour)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Every key is kept and only the last one is active
+	kids := verifier.KeyIDs()
+	require.Len(t, kids, len(signers)+1)
+	active := 0
+	for _, kid := range kids {
+		state, ok := verifier.KeyState(kid)
+		require.True(t, ok)
+		if state == KeyStateActive {
+			active++
+		}
+	}
+	assert.Equal(t, 1, active)
+	assert.Equal(t, kids[0], verifier.KeyID())
+}
+
+func Test_KeyRing_AddKey(t *testing.T) {
+	activeSigner := newTestSigner(t)
+	retiredSigner := newTestSigner(t)
+	verifier, err := NewED25519Verifier(activeSigner.PublicKey())
+	require.NoError(t, err)
+
+	kid, err := verifier.AddKey(retiredSigner.PublicKey(), KeyStateRetired, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, retiredSigner.KeyID(), kid)
+	assert.Equal(t, activeSigner.KeyID(), verifier.KeyID())
+
+	// A token is verified with the key of its key ID
+	token, err := retiredSigner.IssueToken(TokenClaims{}, time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(token, TokenValidation{})
+	assert.NoError(t, err)
+
+	_, err = verifier.AddKey("invalid", KeyStateRetired, time.Time{})
+	assert.Error(t, err)
+	assert.Error(t, verifier.RetireKey("unknown", time.Time{}))
+
+	// SetMasterPublicKey replaces the whole ring
+	require.NoError(t, verifier.SetMasterPublicKey(retiredSigner.PublicKey()))
+	assert.Equal(t, []string{retiredSigner.KeyID()}, verifier.KeyIDs())
+}
+
+func Test_KeyRing_JWKSHandler(t *testing.T) {
+	oldSigner := newTestSigner(t)
+	newSigner := newTestSigner(t)
+	verifier, err := NewED25519Verifier(oldSigner.PublicKey())
+	require.NoError(t, err)
+	handler := verifier.JWKSHandler()
+
+	keyIDs := func() []string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		assert.Equal(t, "application/jwk-set+json", rec.Header().Get("Content-Type"))
+		var jwks JWKS
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+		kids := make([]string, len(jwks.Keys))
+		for i, key := range jwks.Keys {
+			kids[i] = key.KeyID
+		}
+		return kids
+	}
+
+	assert.Equal(t, []string{oldSigner.KeyID()}, keyIDs())
+
+	// The handler serves the keys of the rotation window
+	_, err = verifier.Rotate(newSigner.PublicKey(), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{newSigner.KeyID(), oldSigner.KeyID()}, keyIDs())
+
+	verifier.RemoveKey(oldSigner.KeyID())
+	assert.Equal(t, []string{newSigner.KeyID()}, keyIDs())
+}
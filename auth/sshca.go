@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultSSHUserExtensions are the extensions of user certificates without explicit extensions (like ssh-keygen)
+var DefaultSSHUserExtensions = map[string]string{
+	"permit-X11-forwarding":   "",
+	"permit-agent-forwarding": "",
+	"permit-port-forwarding":  "",
+	"permit-pty":              "",
+	"permit-user-rc":          "",
+}
+
+// SSHCertificateRequest describes an OpenSSH certificate issued by SSHCA
+type SSHCertificateRequest struct {
+	// PublicKey is the key that is certified
+	PublicKey ssh.PublicKey
+	// KeyID identifies the certificate in the logs of sshd
+	KeyID string
+	// Principals are the user names (user certificates) or host names (host certificates) the certificate is valid for.
+	// Host certificates require at least one principal. OpenSSH accepts a user certificate without principals
+	// for every user, so always set them unless that is intended.
+	Principals []string
+	// ValidAfter is the start of the validity window (the current time by default)
+	ValidAfter time.Time
+	// TTL is the length of the validity window and has to be positive
+	TTL time.Duration
+	// CriticalOptions of user certificates (e.g. force-command or source-address)
+	CriticalOptions map[string]string
+	// Extensions of user certificates, DefaultSSHUserExtensions if nil
+	Extensions map[string]string
+}
+
+// SSHCA is an OpenSSH certificate authority signing short-lived user and host certificates with ED25519Signer.SSHSigner
+type SSHCA struct {
+	signer *ED25519Signer
+}
+
+func NewSSHCA(signer *ED25519Signer) *SSHCA {
+	return &SSHCA{signer: signer}
+}
+
+// PublicKey returns the public key of the CA
+func (ca *SSHCA) PublicKey() ssh.PublicKey {
+	return ca.signer.SSHSigner().PublicKey()
+}
+
+// IssueUserCertificate issues a user certificate that allows the key to log in as the principals
+func (ca *SSHCA) IssueUserCertificate(req SSHCertificateRequest) (*ssh.Certificate, error) {
+	extensions := req.Extensions
+	if extensions == nil {
+		extensions = DefaultSSHUserExtensions
+	}
+	return ca.issue(ssh.UserCert, req, maps.Clone(req.CriticalOptions), maps.Clone(extensions))
+}
+
+// IssueHostCertificate issues a host certificate for the host names of the principals,
+// critical options and extensions are not used for host certificates.
+// A host certificate without principals would be accepted for every host, so at least one principal is required.
+func (ca *SSHCA) IssueHostCertificate(req SSHCertificateRequest) (*ssh.Certificate, error) {
+	if len(req.Principals) == 0 {
+		return nil, errors.New("host certificates require principals")
+	}
+	return ca.issue(ssh.HostCert, req, nil, nil)
+}
+
+func (ca *SSHCA) issue(certType uint32, req SSHCertificateRequest, criticalOptions, extensions map[string]string) (*ssh.Certificate, error) {
+	if req.PublicKey == nil {
+		return nil, errors.New("missing public key")
+	}
+	if req.TTL <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	validAfter := req.ValidAfter
+	if validAfter.IsZero() {
+		validAfter = time.Now()
+	}
+
+	var serial [8]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return nil, fmt.Errorf("error generating serial: %w", err)
+	}
+
+	cert := &ssh.Certificate{
+		Key:             req.PublicKey,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        certType,
+		KeyId:           req.KeyID,
+		ValidPrincipals: slices.Clone(req.Principals),
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validAfter.Add(req.TTL).Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: criticalOptions,
+			Extensions:      extensions,
+		},
+	}
+	if err := cert.SignCert(rand.Reader, ca.signer.SSHSigner()); err != nil {
+		return nil, fmt.Errorf("error signing certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// CertSigner issues a user certificate for the key of the signer and returns an ssh.Signer authenticating with it
+// (e.g. for sshbundle.NewClient on a server that trusts the CA, see AuthorizedKeysLine)
+func (ca *SSHCA) CertSigner(signer ssh.Signer, req SSHCertificateRequest) (ssh.Signer, error) {
+	req.PublicKey = signer.PublicKey()
+	cert, err := ca.IssueUserCertificate(req)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewCertSigner(cert, signer)
+}
+
+// AuthorizedKeysLine returns an authorized_keys line that trusts user certificates of the CA
+// (limited to the principals if any are given) instead of raw public keys
+func (ca *SSHCA) AuthorizedKeysLine(principals ...string) string {
+	line := "cert-authority"
+	if len(principals) > 0 {
+		line += `,principals="` + strings.Join(principals, ",") + `"`
+	}
+	return line + " " + strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(ca.PublicKey())), "\n")
+}
+
+// KnownHostsLine returns a known_hosts line that trusts host certificates of the CA for the host patterns
+// (e.g. "*.example.com")
+func (ca *SSHCA) KnownHostsLine(hosts ...string) string {
+	return "@cert-authority " + strings.Join(hosts, ",") + " " +
+		strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(ca.PublicKey())), "\n")
+}
+
+// SSHCertVerifier verifies OpenSSH certificates against the public keys of trusted CAs
+type SSHCertVerifier struct {
+	authorities []ssh.PublicKey
+	checker     *ssh.CertChecker
+}
+
+// NewSSHCertVerifier creates a new SSHCertVerifier for the CA keys which accepts user certificates
+// with the supported critical options only
+func NewSSHCertVerifier(supportedCriticalOptions []string, authorities ...ssh.PublicKey) *SSHCertVerifier {
+	v := &SSHCertVerifier{authorities: authorities}
+	v.checker = &ssh.CertChecker{
+		SupportedCriticalOptions: supportedCriticalOptions,
+		IsUserAuthority:          v.isAuthority,
+		IsHostAuthority: func(auth ssh.PublicKey, _ string) bool {
+			return v.isAuthority(auth)
+		},
+	}
+	return v
+}
+
+func (v *SSHCertVerifier) isAuthority(key ssh.PublicKey) bool {
+	for _, authority := range v.authorities {
+		if bytes.Equal(authority.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyUserCertificate verifies that the user certificate is signed by a trusted CA, currently valid
+// and valid for the principal (user name)
+func (v *SSHCertVerifier) VerifyUserCertificate(cert *ssh.Certificate, principal string) error {
+	if cert.CertType != ssh.UserCert {
+		return errors.New("not a user certificate")
+	}
+	return v.verify(cert, principal)
+}
+
+// VerifyHostCertificate verifies that the host certificate is signed by a trusted CA, currently valid
+// and valid for the host name
+func (v *SSHCertVerifier) VerifyHostCertificate(cert *ssh.Certificate, host string) error {
+	if cert.CertType != ssh.HostCert {
+		return errors.New("not a host certificate")
+	}
+	return v.verify(cert, host)
+}
+
+func (v *SSHCertVerifier) verify(cert *ssh.Certificate, principal string) error {
+	if !v.isAuthority(cert.SignatureKey) {
+		return errors.New("certificate signed by an unknown authority")
+	}
+	return v.checker.CheckCert(principal, cert)
+}
+
+// Authenticate authenticates users by their certificates and can be used as ssh.ServerConfig.PublicKeyCallback
+func (v *SSHCertVerifier) Authenticate(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	return v.checker.Authenticate(conn, key)
+}
+
+// CheckHostKey verifies host certificates and can be used as ssh.ClientConfig.HostKeyCallback
+func (v *SSHCertVerifier) CheckHostKey(addr string, remote net.Addr, key ssh.PublicKey) error {
+	return v.checker.CheckHostKey(addr, remote, key)
+}
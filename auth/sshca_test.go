@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func Test_SSHCA_UserCertificate(t *testing.T) {
+	ca := NewSSHCA(newTestSigner(t))
+	user := newTestSigner(t)
+
+	cert, err := ca.IssueUserCertificate(SSHCertificateRequest{
+		PublicKey:       user.SSHSigner().PublicKey(),
+		KeyID:           "user@example.com",
+		Principals:      []string{"root", "deploy"},
+		TTL:             time.Hour,
+		CriticalOptions: map[string]string{"force-command": "uptime"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(ssh.UserCert), cert.CertType)
+	assert.Equal(t, DefaultSSHUserExtensions, cert.Extensions)
+
+	verifier := NewSSHCertVerifier([]string{"force-command"}, ca.PublicKey())
+	assert.NoError(t, verifier.VerifyUserCertificate(cert, "deploy"))
+	assert.Error(t, verifier.VerifyUserCertificate(cert, "other"))
+	assert.Error(t, verifier.VerifyHostCertificate(cert, "deploy"))
+	assert.Error(t, NewSSHCertVerifier(nil, ca.PublicKey()).VerifyUserCertificate(cert, "deploy"),
+		"unsupported critical options must be rejected")
+	assert.Error(t, NewSSHCertVerifier([]string{"force-command"}, user.SSHSigner().PublicKey()).VerifyUserCertificate(cert, "deploy"),
+		"certificates of unknown authorities must be rejected")
+
+	expired, err := ca.IssueUserCertificate(SSHCertificateRequest{
+		PublicKey:  user.SSHSigner().PublicKey(),
+		ValidAfter: time.Now().Add(-2 * time.Hour),
+		TTL:        time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Error(t, verifier.VerifyUserCertificate(expired, "root"))
+
+	_, err = ca.IssueUserCertificate(SSHCertificateRequest{PublicKey: user.SSHSigner().PublicKey()})
+	assert.Error(t, err)
+}
+
+func Test_SSHCA_HostCertificate(t *testing.T) {
+	ca := NewSSHCA(newTestSigner(t))
+	host := newTestSigner(t)
+
+	cert, err := ca.IssueHostCertificate(SSHCertificateRequest{
+		PublicKey:  host.SSHSigner().PublicKey(),
+		Principals: []string{"host.example.com"},
+		TTL:        time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(ssh.HostCert), cert.CertType)
+
+	verifier := NewSSHCertVerifier(nil, ca.PublicKey())
+	assert.NoError(t, verifier.VerifyHostCertificate(cert, "host.example.com"))
+	assert.NoError(t, verifier.CheckHostKey("host.example.com:22", &net.TCPAddr{}, cert))
+	assert.Error(t, verifier.CheckHostKey("other.example.com:22", &net.TCPAddr{}, cert))
+	assert.Error(t, verifier.CheckHostKey("host.example.com:22", &net.TCPAddr{}, host.SSHSigner().PublicKey()))
+
+	// A host certificate without principals would be valid for every host
+	_, err = ca.IssueHostCertificate(SSHCertificateRequest{
+		PublicKey: host.SSHSigner().PublicKey(),
+		TTL:       time.Hour,
+	})
+	assert.Error(t, err)
+}
+
+func Test_SSHCA_Handshake(t *testing.T) {
+	ca := NewSSHCA(newTestSigner(t))
+	verifier := NewSSHCertVerifier(nil, ca.PublicKey())
+
+	hostKey := newTestSigner(t).SSHSigner()
+	hostCert, err := ca.IssueHostCertificate(SSHCertificateRequest{
+		PublicKey:  hostKey.PublicKey(),
+		Principals: []string{"127.0.0.1"},
+		TTL:        time.Hour,
+	})
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewCertSigner(hostCert, hostKey)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{PublicKeyCallback: verifier.Authenticate}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for ch := range chans {
+			_ = ch.Reject(ssh.Prohibited, "")
+		}
+	}()
+
+	userSigner, err := ca.CertSigner(newTestSigner(t).SSHSigner(), SSHCertificateRequest{
+		Principals: []string{"deploy"},
+		TTL:        time.Minute,
+	})
+	require.NoError(t, err)
+
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:              "deploy",
+		Auth:              []ssh.AuthMethod{ssh.PublicKeys(userSigner)},
+		HostKeyCallback:   verifier.CheckHostKey,
+		HostKeyAlgorithms: []string{ssh.CertAlgoED25519v01},
+	})
+	require.NoError(t, err)
+	_ = client.Close()
+}
+
+func Test_SSHCA_Lines(t *testing.T) {
+	ca := NewSSHCA(newTestSigner(t))
+	assert.Regexp(t, `^cert-authority,principals="root,deploy" ssh-ed25519 \S+$`, ca.AuthorizedKeysLine("root", "deploy"))
+	assert.Regexp(t, `^cert-authority ssh-ed25519 \S+$`, ca.AuthorizedKeysLine())
+	assert.Regexp(t, `^@cert-authority \*\.example\.com ssh-ed25519 \S+$`, ca.KnownHostsLine("*.example.com"))
+
+	_, _, options, _, err := ssh.ParseAuthorizedKey([]byte(ca.AuthorizedKeysLine("root")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cert-authority", `principals="root"`}, options)
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// ed25519phOptions select Ed25519ph (RFC 8032), which signs the SHA-512 digest of a message instead of the message
+var ed25519phOptions = &ed25519.Options{Hash: crypto.SHA512}
+
+// SignReader signs the content of the reader with Ed25519ph and returns the base64 encoded signature.
+// The content is hashed while it is read, so large payloads (e.g. files) are not loaded into memory.
+// Ed25519ph signatures are not compatible with Sign and are verified with VerifyReader.
+// The crypto.Signer of NewED25519SignerFromSigner has to support Ed25519ph (ed25519.Options with crypto.SHA512),
+// most external signers (e.g. ssh agents and HSMs) only support pure Ed25519 and SignReader returns their error.
+func (s *ED25519Signer) SignReader(r io.Reader) (string, error) {
+	digest, err := sha512Digest(r)
+	if err != nil {
+		return "", err
+	}
+	signature, err := s.signer.Sign(rand.Reader, digest, ed25519phOptions)
+	if err != nil {
+		return "", fmt.Errorf("error signing digest: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// VerifyReader verifies an Ed25519ph signature of SignReader for the content of the reader with the valid keys of the ring.
+// It returns ErrInvalidBase64 or ErrInvalidSignatureSize if the signature is malformed and ErrBadSignature if it is not valid.
+func (v *ED25519Verifier) VerifyReader(r io.Reader, signature string) error {
+	signatureBytes, err := decodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	digest, err := sha512Digest(r)
+	if err != nil {
+		return err
+	}
+	for _, publicKey := range v.candidates("") {
+		if ed25519.VerifyWithOptions(publicKey, digest, signatureBytes, ed25519phOptions) == nil {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+func sha512Digest(r io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("error reading message: %w", err)
+	}
+	return h.Sum(nil), nil
+}
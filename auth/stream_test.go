@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignReader(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	payload := make([]byte, 1<<20)
+	_, err = rand.Read(payload)
+	require.NoError(t, err)
+
+	signature, err := signer.SignReader(bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.NoError(t, verifier.VerifyReader(bytes.NewReader(payload), signature))
+
+	// Ed25519ph signatures are not valid Ed25519 signatures of the same message
+	assert.False(t, verifier.Verify(string(payload), signature))
+
+	payload[0] ^= 1
+	assert.ErrorIs(t, verifier.VerifyReader(bytes.NewReader(payload), signature), ErrBadSignature)
+	assert.ErrorIs(t, verifier.VerifyReader(bytes.NewReader(payload), "!"), ErrInvalidBase64)
+}
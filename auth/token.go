@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pedramktb/go-base-lib/taggederror"
+)
+
+var (
+	ErrTokenMalformed = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("malformed token"), "TOKEN_MALFORMED"),
+	)
+	ErrTokenBadSignature = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("invalid token signature"), "TOKEN_BAD_SIGNATURE"),
+	)
+	ErrTokenExpired = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("token expired"), "TOKEN_EXPIRED"),
+	)
+	ErrTokenNotYetValid = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("token not yet valid"), "TOKEN_NOT_YET_VALID"),
+	)
+	ErrTokenInvalidIssuer = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("invalid token issuer"), "TOKEN_INVALID_ISSUER"),
+	)
+	ErrTokenInvalidAudience = taggederror.ErrUnauthorized.Wrap(
+		taggederror.New(errors.New("invalid token audience"), "TOKEN_INVALID_AUDIENCE"),
+	)
+)
+
+// TokenClaims are the claims of a token issued by ED25519Signer.IssueToken.
+// The token is the base64url encoded JSON of the claims and its base64url encoded signature joined by a dot.
+// The signature covers a token context prefix and the encoded claims, so it differs from a Sign signature of the claims.
+type TokenClaims struct {
+	Issuer    string
+	Audience  string
+	Subject   string
+	KeyID     string
+	IssuedAt  time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+	// Data holds custom claims as JSON
+	Data json.RawMessage
+}
+
+// tokenPayload is the encoded form of TokenClaims with unix timestamps
+type tokenPayload struct {
+	Issuer    string          `json:"iss,omitempty"`
+	Audience  string          `json:"aud,omitempty"`
+	Subject   string          `json:"sub,omitempty"`
+	KeyID     string          `json:"kid,omitempty"`
+	IssuedAt  int64           `json:"iat"`
+	NotBefore int64           `json:"nbf,omitempty"`
+	ExpiresAt int64           `json:"exp"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// TokenValidation configures the validation of tokens, empty fields are not validated
+type TokenValidation struct {
+	Issuer   string
+	Audience string
+	// ClockSkew is the tolerance for the expiry and not before times
+	ClockSkew time.Duration
+	// Now returns the current time (time.Now by default)
+	Now func() time.Time
+}
+
+// tokenContext prefixes the signed message of tokens, so signatures of Sign can not be used as token signatures
+const tokenContext = "go-base-lib/auth token\x00"
+
+// keyID returns a short identifier of the public key
+func keyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+// KeyID returns a short identifier of the master private key's public key
+func (s *ED25519Signer) KeyID() string {
+	return keyID(s.publicKey)
+}
+
+// IssueToken issues a token with the claims that expires after ttl.
+// IssuedAt, ExpiresAt and KeyID are set by the signer.
+func (s *ED25519Signer) IssueToken(claims TokenClaims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	payload := tokenPayload{
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		Subject:   claims.Subject,
+		KeyID:     s.KeyID(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		Data:      claims.Data,
+	}
+	if !claims.NotBefore.IsZero() {
+		payload.NotBefore = claims.NotBefore.Unix()
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	message := base64.RawURLEncoding.EncodeToString(encoded)
+	signature, err := s.sign([]byte(tokenContext + message))
+	if err != nil {
+		return "", err
+	}
+	return message + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyToken verifies the signature of a token issued by ED25519Signer.IssueToken and validates its claims.
+// The token is verified with the key of its key ID or with all valid keys if the key ID is unknown.
+// The returned errors are ErrTokenMalformed, ErrTokenBadSignature, ErrTokenExpired, ErrTokenNotYetValid,
+// ErrTokenInvalidIssuer or ErrTokenInvalidAudience.
+func (v *ED25519Verifier) VerifyToken(token string, validation TokenValidation) (*TokenClaims, error) {
+	message, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrTokenMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, ErrTokenMalformed
+	}
+
+	// The payload is decoded before verification to select the key by its ID
+	encoded, err := base64.RawURLEncoding.DecodeString(message)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var payload tokenPayload
+	if err := json.Unmarshal(encoded, &payload); err != nil {
+		return nil, ErrTokenMalformed.Wrap(err)
+	}
+
+	if !v.verify(payload.KeyID, []byte(tokenContext+message), signature) {
+		return nil, ErrTokenBadSignature
+	}
+
+	claims := &TokenClaims{
+		Issuer:    payload.Issuer,
+		Audience:  payload.Audience,
+		Subject:   payload.Subject,
+		KeyID:     payload.KeyID,
+		IssuedAt:  time.Unix(payload.IssuedAt, 0),
+		ExpiresAt: time.Unix(payload.ExpiresAt, 0),
+		Data:      payload.Data,
+	}
+	if payload.NotBefore != 0 {
+		claims.NotBefore = time.Unix(payload.NotBefore, 0)
+	}
+
+	var audiences []string
+	if claims.Audience != "" {
+		audiences = []string{claims.Audience}
+	}
+	if err := validation.validate(claims.Issuer, audiences, claims.NotBefore, claims.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (val TokenValidation) validate(issuer string, audiences []string, notBefore, expiresAt time.Time) error {
+	now := time.Now()
+	if val.Now != nil {
+		now = val.Now()
+	}
+	if !expiresAt.IsZero() && !now.Before(expiresAt.Add(val.ClockSkew)) {
+		return ErrTokenExpired
+	}
+	if !notBefore.IsZero() && now.Add(val.ClockSkew).Before(notBefore) {
+		return ErrTokenNotYetValid
+	}
+	if val.Issuer != "" && issuer != val.Issuer {
+		return ErrTokenInvalidIssuer
+	}
+	if val.Audience != "" && !slices.Contains(audiences, val.Audience) {
+		return ErrTokenInvalidAudience
+	}
+	return nil
+}
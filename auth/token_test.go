@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedramktb/go-base-lib/taggederror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSigner(t *testing.T) *ED25519Signer {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	_, err := rand.Read(seed)
+	require.NoError(t, err)
+	signer, err := NewED25519Signer(base64.StdEncoding.EncodeToString(seed))
+	require.NoError(t, err)
+	return signer
+}
+
+func Test_Token(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	token, err := signer.IssueToken(TokenClaims{
+		Issuer:   "issuer",
+		Audience: "audience",
+		Subject:  "subject",
+		Data:     json.RawMessage(`{"role":"admin"}`),
+	}, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := verifier.VerifyToken(token, TokenValidation{Issuer: "issuer", Audience: "audience"})
+	require.NoError(t, err)
+	assert.Equal(t, "subject", claims.Subject)
+	assert.Equal(t, signer.KeyID(), claims.KeyID)
+	assert.JSONEq(t, `{"role":"admin"}`, string(claims.Data))
+
+	future, err := signer.IssueToken(TokenClaims{NotBefore: time.Now().Add(time.Hour)}, 2*time.Hour)
+	require.NoError(t, err)
+
+	otherVerifier, err := NewED25519Verifier(newTestSigner(t).PublicKey())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		verifier   *ED25519Verifier
+		token      string
+		validation TokenValidation
+		want       error
+	}{
+		{
+			name:  "malformed",
+			token: "abc",
+			want:  ErrTokenMalformed,
+		},
+		{
+			name:  "tampered",
+			token: "e30" + token[strings.Index(token, "."):],
+			want:  ErrTokenBadSignature,
+		},
+		{
+			name:     "other key",
+			verifier: otherVerifier,
+			token:    token,
+			want:     ErrTokenBadSignature,
+		},
+		{
+			name:       "expired",
+			token:      token,
+			validation: TokenValidation{Now: func() time.Time { return time.Now().Add(2 * time.Minute) }},
+			want:       ErrTokenExpired,
+		},
+		{
+			name:  "expired within clock skew",
+			token: token,
+			validation: TokenValidation{
+				Now:       func() time.Time { return time.Now().Add(2 * time.Minute) },
+				ClockSkew: 2 * time.Minute,
+			},
+		},
+		{
+			name:  "not yet valid",
+			token: future,
+			want:  ErrTokenNotYetValid,
+		},
+		{
+			name:       "issuer",
+			token:      token,
+			validation: TokenValidation{Issuer: "other"},
+			want:       ErrTokenInvalidIssuer,
+		},
+		{
+			name:       "audience",
+			token:      token,
+			validation: TokenValidation{Audience: "other"},
+			want:       ErrTokenInvalidAudience,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := verifier
+			if tt.verifier != nil {
+				v = tt.verifier
+			}
+			_, err := v.VerifyToken(tt.token, tt.validation)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+			var taggedErr *taggederror.Error
+			if assert.True(t, errors.As(err, &taggedErr)) {
+				assert.Equal(t, http.StatusUnauthorized, taggedErr.Code())
+			}
+		})
+	}
+}
+
+func Test_Token_SignedMessage(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+
+	// A signature of Sign over encoded claims is not a valid token signature
+	payload, err := json.Marshal(tokenPayload{Subject: "admin", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	message := base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := base64.StdEncoding.DecodeString(signer.Sign(message))
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(message+"."+base64.RawURLEncoding.EncodeToString(signature), TokenValidation{})
+	assert.ErrorIs(t, err, ErrTokenBadSignature)
+}
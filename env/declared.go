@@ -0,0 +1,127 @@
+package env
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the values and defaults of secret environment variables in Declared
+const Redacted = "[REDACTED]"
+
+// Var describes an environment variable declared through the getters or Load
+type Var struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+	// Value is the raw value (or the default if FromDefault is true), empty if the variable is not set
+	Value       string `json:"value"`
+	FromDefault bool   `json:"fromDefault"`
+}
+
+var (
+	declaredMu   sync.Mutex
+	declared     []Var
+	declaredIdxs = map[string]int{}
+	secrets      = map[string]bool{}
+)
+
+// declare records a declaration, a later declaration of the same variable replaces the earlier one
+func declare(v Var) {
+	declaredMu.Lock()
+	defer declaredMu.Unlock()
+	if i, ok := declaredIdxs[v.Name]; ok {
+		declared[i] = v
+		return
+	}
+	declaredIdxs[v.Name] = len(declared)
+	declared = append(declared, v)
+}
+
+// MarkSecret marks environment variables as secret so their values are redacted in Declared.
+// Variables read from NAME_FILE and Load fields with a secret:"true" tag are always secret.
+func MarkSecret(names ...string) {
+	declaredMu.Lock()
+	defer declaredMu.Unlock()
+	for _, name := range names {
+		secrets[name] = true
+	}
+}
+
+// Declared returns all environment variables declared so far through the getters and Load in order of declaration.
+// The values and defaults of secret variables are redacted.
+func Declared() Vars {
+	declaredMu.Lock()
+	defer declaredMu.Unlock()
+	vars := make(Vars, len(declared))
+	for i, v := range declared {
+		v.Secret = v.Secret || secrets[v.Name]
+		if v.Secret {
+			if v.Value != "" {
+				v.Value = Redacted
+			}
+			if v.Default != "" {
+				v.Default = Redacted
+			}
+		}
+		vars[i] = v
+	}
+	return vars
+}
+
+// Vars is a list of declared environment variables, it can be logged with slog (e.g. the effective config at startup),
+// rendered as a markdown reference or marshaled to JSON
+type Vars []Var
+
+// LogValue groups the values of the variables by their names
+func (vs Vars) LogValue() slog.Value {
+	attrs := make([]slog.Attr, len(vs))
+	for i, v := range vs {
+		attrs[i] = slog.String(v.Name, v.Value)
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Markdown renders the variables as a markdown table without their current values
+func (vs Vars) Markdown() string {
+	sb := strings.Builder{}
+	sb.WriteString("| Name | Type | Required | Default | Description |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, v := range vs {
+		def := ""
+		if v.Default != "" {
+			def = "`" + v.Default + "`"
+		}
+		sb.WriteString("| `" + v.Name + "` | `" + v.Type + "` | " + strconv.FormatBool(v.Required) + " | " +
+			escapeMarkdown(def) + " | " + escapeMarkdown(v.Description) + " |\n")
+	}
+	return sb.String()
+}
+
+// format formats a value for Declared like it would be set in an environment variable
+func format(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return ""
+		}
+	}
+	if _, ok := v.Interface().(fmt.Stringer); !ok && v.Kind() == reflect.Slice {
+		elems := make([]string, v.Len())
+		for i := range v.Len() {
+			elems[i] = format(v.Index(i))
+		}
+		return strings.Join(elems, defaultSplitOptions.sep)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,84 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Declared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("t0k3n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	defer SetSources(Map{
+		"DECLARED_HOST":       "localhost",
+		"DECLARED_PASSWORD":   "p4ssw0rd",
+		"DECLARED_TOKEN_FILE": path,
+		"DECLARED_DB_KEY":     "k3y",
+	})()
+
+	type config struct {
+		Port int `env:"DECLARED_PORT" default:"8080" desc:"port | of the server"`
+		DB   struct {
+			Key string `env:"KEY" secret:"true"`
+		} `prefix:"DECLARED_DB_"`
+	}
+
+	MarkSecret("DECLARED_PASSWORD")
+	_, _ = GetOrFail[string]("DECLARED_HOST")
+	_, _ = GetOrFail[string]("DECLARED_PASSWORD")
+	_, _ = GetOrFail[string]("DECLARED_TOKEN")
+	_, _ = GetWithFallback("DECLARED_HOSTS", []string{"a", "b"})
+	_, _ = GetOrFail[int]("DECLARED_MISSING")
+	if err := Load(&config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var vars Vars
+	for _, v := range Declared() {
+		if strings.HasPrefix(v.Name, "DECLARED_") {
+			vars = append(vars, v)
+		}
+	}
+
+	want := Vars{
+		{Name: "DECLARED_HOST", Type: "string", Required: true, Value: "localhost"},
+		{Name: "DECLARED_PASSWORD", Type: "string", Required: true, Secret: true, Value: Redacted},
+		{Name: "DECLARED_TOKEN", Type: "string", Required: true, Secret: true, Value: Redacted},
+		{Name: "DECLARED_HOSTS", Type: "[]string", Default: "a,b", Value: "a,b", FromDefault: true},
+		{Name: "DECLARED_MISSING", Type: "int", Required: true},
+		{Name: "DECLARED_PORT", Type: "int", Description: "port | of the server", Default: "8080", Value: "8080", FromDefault: true},
+		{Name: "DECLARED_DB_KEY", Type: "string", Secret: true, Value: Redacted},
+	}
+	if len(vars) != len(want) {
+		t.Fatalf("got %+v, expected %+v", vars, want)
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Errorf("got %+v, expected %+v", vars[i], want[i])
+		}
+	}
+
+	buf := bytes.Buffer{}
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("config", "env", vars)
+	if !strings.Contains(buf.String(), "env.DECLARED_HOST=localhost env.DECLARED_PASSWORD=[REDACTED]") {
+		t.Errorf("unexpected log output: %s", buf.String())
+	}
+
+	if md := vars.Markdown(); !strings.Contains(md, "| `DECLARED_PORT` | `int` | false | `8080` | port \\| of the server |\n") {
+		t.Errorf("unexpected markdown: %s", md)
+	}
+
+	j, err := json.Marshal(vars)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(j), "p4ssw0rd") || strings.Contains(string(j), "t0k3n") || strings.Contains(string(j), "k3y") {
+		t.Errorf("secret in json: %s", j)
+	}
+}
@@ -1,9 +1,10 @@
 package env
 
 import (
+	"cmp"
 	"errors"
-	"os"
-	"strconv"
+	"fmt"
+	"reflect"
 )
 
 type Environment string
@@ -31,149 +32,115 @@ func SetEnvironment(e Environment) {
 	envSet = true
 }
 
-func GetEnvironment() Environment {
+// ParseEnvironment parses the name of a known environment including registered ones
+// ("local" and "" are both EnvironmentLocal)
+func ParseEnvironment(name string) (Environment, error) {
+	e := Environment(name)
+	if name == EnvironmentLocal.String() {
+		e = EnvironmentLocal
+	}
+	if err := OneOf(Environments()...)(e); err != nil {
+		return "", err
+	}
+	return e, nil
+}
+
+func (e *Environment) UnmarshalText(text []byte) error {
+	parsed, err := ParseEnvironment(string(text))
+	if err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
+
+// LookupEnvironment returns the environment set by SetEnvironment or the ENVIRONMENT variable
+// and returns an error if the environment is unknown
+func LookupEnvironment() (Environment, error) {
 	if envSet {
-		return env
+		return env, nil
+	}
+	name, err := GetWithFallback("ENVIRONMENT", "")
+	if err != nil {
+		return EnvironmentProd, err
+	}
+	e, err := ParseEnvironment(name)
+	if err != nil {
+		return EnvironmentProd, fmt.Errorf("invalid env variable ENVIRONMENT: %w", err)
 	}
-	e, _ := GetWithFallback("ENVIRONMENT", string(EnvironmentLocal))
-	return Environment(e)
+	return e, nil
 }
 
-// GetOrFail returns the value of the environment variable or returns an error if it is not set
-func GetOrFail[ //NOSONAR
-	T string |
-		bool |
-		uintptr |
-		int | int64 | int32 | int16 | int8 |
-		uint | uint64 | uint32 | uint16 | uint8 |
-		float64 | float32 |
-		complex128 | complex64](env string) (T, error) {
-	str := os.Getenv(env)
+// GetEnvironment returns the environment set by SetEnvironment or the ENVIRONMENT variable.
+// An unknown environment (e.g. a typo like "prdo") is treated as EnvironmentProd,
+// so prod-only behavior is never turned off by accident. Use LookupEnvironment at startup to fail on it instead.
+func GetEnvironment() Environment {
+	e, _ := LookupEnvironment()
+	return e
+}
+
+// GetOrFail returns the value of the environment variable or returns an error if it is not set.
+// The value is looked up in the active sources, by default only the OS environment (see SetSources).
+// If the environment variable is not set but NAME_FILE is, the content of that file is used instead.
+// Supported types are strings, bools, numbers, time.Duration, time.Time (RFC3339), *url.URL,
+// net.IP, *net.IPNet, *net.UDPAddr, types implementing encoding.TextUnmarshaler (e.g. netip.Addr, netip.Prefix),
+// slices and maps of the above (see GetSliceOrFail and GetMapOrFail for the format)
+// and other types with an underlying string, bool or number type.
+// The parsed value is checked by the validators (e.g. Min, Max, OneOf, Regex or a custom func).
+func GetOrFail[T any](env string, validators ...Validator[T]) (T, error) {
+	str, file, err := lookupFile(env)
+	declare(Var{
+		Name:     env,
+		Type:     reflect.TypeFor[T]().String(),
+		Required: true,
+		Secret:   file,
+		Value:    str,
+	})
+	if err != nil {
+		return *new(T), err
+	}
 	if str == "" {
 		return *new(T), errors.New("missing env variable: " + env)
 	}
 
-	var val any
-
-	switch any(*new(T)).(type) {
-	case string:
-		val = str
-	case bool:
-		b, err := strconv.ParseBool(str)
-		if err != nil {
-			return *new(T), errors.New("invalid bool: " + env)
-		}
-		val = b
-	case uintptr:
-		u64, err := strconv.ParseUint(str, 10, 0)
-		if err != nil {
-			return *new(T), errors.New("invalid uintptr: " + env)
-		}
-		val = uintptr(u64)
-	case int:
-		i64, err := strconv.ParseInt(str, 10, 0)
-		if err != nil {
-			return *new(T), errors.New("invalid int: " + env)
-		}
-		val = int(i64)
-	case int64:
-		i64, err := strconv.ParseInt(str, 10, 64)
-		if err != nil {
-			return *new(T), errors.New("invalid int64: " + env)
-		}
-		val = i64
-	case int32:
-		i64, err := strconv.ParseInt(str, 10, 32)
-		if err != nil {
-			return *new(T), errors.New("invalid int32: " + env)
-		}
-		val = int32(i64)
-	case int16:
-		i64, err := strconv.ParseInt(str, 10, 16)
-		if err != nil {
-			return *new(T), errors.New("invalid int16: " + env)
-		}
-		val = int16(i64)
-	case int8:
-		i64, err := strconv.ParseInt(str, 10, 8)
-		if err != nil {
-			return *new(T), errors.New("invalid int8: " + env)
-		}
-		val = int8(i64)
-	case uint:
-		i64, err := strconv.ParseUint(str, 10, 0)
-		if err != nil {
-			return *new(T), errors.New("invalid uint: " + env)
-		}
-		val = uint(i64)
-	case uint64:
-		i64, err := strconv.ParseUint(str, 10, 64)
-		if err != nil {
-			return *new(T), errors.New("invalid uint64: " + env)
-		}
-		val = i64
-	case uint32:
-		i64, err := strconv.ParseUint(str, 10, 32)
-		if err != nil {
-			return *new(T), errors.New("invalid uint32: " + env)
-		}
-		val = uint32(i64)
-	case uint16:
-		i64, err := strconv.ParseUint(str, 10, 16)
-		if err != nil {
-			return *new(T), errors.New("invalid uint16: " + env)
-		}
-		val = uint16(i64)
-	case uint8:
-		i64, err := strconv.ParseUint(str, 10, 8)
-		if err != nil {
-			return *new(T), errors.New("invalid uint8: " + env)
-		}
-		val = uint8(i64)
-	case float64:
-		f64, err := strconv.ParseFloat(str, 64)
-		if err != nil {
-			return *new(T), errors.New("invalid float64: " + env)
-		}
-		val = f64
-	case float32:
-		f64, err := strconv.ParseFloat(str, 32)
-		if err != nil {
-			return *new(T), errors.New("invalid float32: " + env)
-		}
-		val = float32(f64)
-	case complex128:
-		c128, err := strconv.ParseComplex(str, 128)
-		if err != nil {
-			return *new(T), errors.New("invalid complex128: " + env)
-		}
-		val = c128
-	case complex64:
-		c128, err := strconv.ParseComplex(str, 64)
-		if err != nil {
-			return *new(T), errors.New("invalid complex64: " + env)
-		}
-		val = complex64(c128)
-	default:
-		return *new(T), errors.New("unsupported type")
+	var val T
+	if err := parse(env, str, reflect.ValueOf(&val).Elem(), defaultSplitOptions); err != nil {
+		return *new(T), err
+	}
+	if err := validate(env, val, validators); err != nil {
+		return *new(T), err
 	}
 
-	return val.(T), nil
+	return val, nil
 }
 
-// GetWithFallback returns the value of the environment variable or the fallback value if it is not set
-func GetWithFallback[
-	T string |
-		bool |
-		uintptr |
-		int | int64 | int32 | int16 | int8 |
-		uint | uint64 | uint32 | uint16 | uint8 |
-		float64 | float32 |
-		complex128 | complex64](env string, fallback T) (T, error) {
-	str := os.Getenv(env)
+// GetWithFallback returns the value of the environment variable (or NAME_FILE) or the fallback value if it is not set.
+// Only a set value is checked by the validators.
+func GetWithFallback[T any](env string, fallback T, validators ...Validator[T]) (T, error) {
+	str, file, err := lookupFile(env)
+	def := format(reflect.ValueOf(&fallback).Elem())
+	declare(Var{
+		Name:        env,
+		Type:        reflect.TypeFor[T]().String(),
+		Default:     def,
+		Secret:      file,
+		Value:       cmp.Or(str, def),
+		FromDefault: str == "",
+	})
+	if err != nil {
+		return *new(T), err
+	}
 	if str == "" {
 		return fallback, nil
 	}
 
-	return GetOrFail[T](env)
+	var val T
+	if err := parse(env, str, reflect.ValueOf(&val).Elem(), defaultSplitOptions); err != nil {
+		return *new(T), err
+	}
+	if err := validate(env, val, validators); err != nil {
+		return *new(T), err
+	}
+
+	return val, nil
 }
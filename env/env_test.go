@@ -1,8 +1,13 @@
 package env
 
 import (
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 )
 
 func Test_GetOrFail(t *testing.T) {
@@ -183,3 +188,60 @@ func Test_GetOrFail(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetOrFail_Types(t *testing.T) {
+	t.Setenv("DURATION", "1m30s")
+	if got, err := GetOrFail[time.Duration]("DURATION"); err != nil || got != 90*time.Second {
+		t.Errorf("got %v (%v), expected %v", got, err, 90*time.Second)
+	}
+
+	t.Setenv("TIME", "2025-01-02T03:04:05Z")
+	if got, err := GetOrFail[time.Time]("TIME"); err != nil || !got.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("got %v (%v), expected 2025-01-02T03:04:05Z", got, err)
+	}
+
+	t.Setenv("URL", "https://example.com/path?q=1")
+	if got, err := GetOrFail[*url.URL]("URL"); err != nil || got.Host != "example.com" || got.Path != "/path" {
+		t.Errorf("got %v (%v), expected https://example.com/path?q=1", got, err)
+	}
+
+	t.Setenv("IP", "10.0.0.1")
+	if got, err := GetOrFail[net.IP]("IP"); err != nil || !got.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("got %v (%v), expected 10.0.0.1", got, err)
+	}
+	if got, err := GetOrFail[netip.Addr]("IP"); err != nil || got != netip.MustParseAddr("10.0.0.1") {
+		t.Errorf("got %v (%v), expected 10.0.0.1", got, err)
+	}
+
+	t.Setenv("CIDR", "10.0.0.1/24")
+	if got, err := GetOrFail[*net.IPNet]("CIDR"); err != nil || got.String() != "10.0.0.0/24" {
+		t.Errorf("got %v (%v), expected 10.0.0.0/24", got, err)
+	}
+	if got, err := GetOrFail[netip.Prefix]("CIDR"); err != nil || got != netip.MustParsePrefix("10.0.0.1/24") {
+		t.Errorf("got %v (%v), expected 10.0.0.1/24", got, err)
+	}
+
+	t.Setenv("UDP_ADDR", "10.0.0.1:53")
+	if got, err := GetOrFail[*net.UDPAddr]("UDP_ADDR"); err != nil || got.String() != "10.0.0.1:53" {
+		t.Errorf("got %v (%v), expected 10.0.0.1:53", got, err)
+	}
+
+	t.Setenv("LEVEL", "warn")
+	if got, err := GetOrFail[slog.Level]("LEVEL"); err != nil || got != slog.LevelWarn {
+		t.Errorf("got %v (%v), expected %v", got, err, slog.LevelWarn)
+	}
+
+	t.Setenv("ADDR_PTR", "::1")
+	if got, err := GetOrFail[*netip.Addr]("ADDR_PTR"); err != nil || got == nil || *got != netip.IPv6Loopback() {
+		t.Errorf("got %v (%v), expected ::1", got, err)
+	}
+
+	t.Setenv("INVALID_IP", "10.0.0")
+	if _, err := GetOrFail[net.IP]("INVALID_IP"); err == nil || err.Error() != "invalid net.IP: INVALID_IP" {
+		t.Errorf("got %v, expected invalid net.IP: INVALID_IP", err)
+	}
+
+	if _, err := GetOrFail[[]chan int]("INVALID_IP"); err == nil {
+		t.Error("expected error for unsupported type but got none")
+	}
+}
@@ -0,0 +1,35 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// lookup returns the value of the environment variable from the active sources.
+// If it is not set, the content of the file at NAME_FILE is returned without trailing newlines
+// (Docker/Kubernetes secrets convention).
+func lookup(env string) (string, error) {
+	str, _, err := lookupFile(env)
+	return str, err
+}
+
+// lookupFile is lookup that also returns whether the value was read from NAME_FILE
+func lookupFile(env string) (str string, file bool, err error) {
+	src := activeSource()
+	if str, _ := src.Lookup(env); str != "" {
+		return str, false, nil
+	}
+
+	path, _ := src.Lookup(env + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", true, fmt.Errorf("error reading env variable file %s: %w", env+"_FILE", err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
@@ -0,0 +1,32 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_GetOrFail_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FILE_SECRET_FILE", path)
+
+	if got, err := GetOrFail[string]("FILE_SECRET"); err != nil || got != "s3cr3t" {
+		t.Errorf("got %q (%v), expected s3cr3t", got, err)
+	}
+	if got, err := GetWithFallback("FILE_SECRET", "fallback"); err != nil || got != "s3cr3t" {
+		t.Errorf("got %q (%v), expected s3cr3t", got, err)
+	}
+
+	t.Setenv("FILE_SECRET", "env")
+	if got, err := GetOrFail[string]("FILE_SECRET"); err != nil || got != "env" {
+		t.Errorf("got %q (%v), expected env", got, err)
+	}
+
+	t.Setenv("FILE_MISSING_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := GetWithFallback("FILE_MISSING", "fallback"); err == nil {
+		t.Error("expected error but got none")
+	}
+}
@@ -0,0 +1,117 @@
+package env
+
+import (
+	"cmp"
+	"errors"
+	"reflect"
+)
+
+// Load fills the struct pointed to by cfg from environment variables using the following field tags:
+//
+//	env:"NAME"       the environment variable of the field (NAME_FILE is used if NAME is not set)
+//	default:"VALUE"  the value used if the environment variable is not set
+//	default_prod:"V" the default in a certain environment (e.g. default_local or default_qa), overrides default
+//	required:"true"  fails if the environment variable is not set and there is no default
+//	prefix:"DB_"     the prefix for the environment variables of a nested struct (without an env tag)
+//	sep:";"          the separator between slice elements or map entries (default ",")
+//	kvsep:":"        the separator between the key and the value of map entries (default "=")
+//	validate:"..."   comma separated rules: nonempty, min=1, max=10, oneof=a b c or a name passed to RegisterValidator
+//	                 (min and max limit the length of strings, slices and maps)
+//	regex:"^[a-z]+$" a pattern the value of a string field has to match
+//	secret:"true"    redacts the value in Declared
+//	desc:"..."       a description of the environment variable for Declared
+//
+// Example:
+//
+//	type Config struct {
+//		Port int    `env:"PORT" default:"8080" validate:"min=1,max=65535"`
+//		Key  string `env:"MASTER_KEY" required:"true" secret:"true"`
+//		DB   struct {
+//			URL string `env:"URL" required:"true"` // DB_URL
+//		} `prefix:"DB_"`
+//	}
+//
+// Unlike GetOrFail, Load does not stop at the first failure.
+// Every missing or invalid environment variable is reported in one joined error.
+func Load(cfg any) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("cfg must be a non-nil pointer to a struct")
+	}
+	return errors.Join(load(v.Elem(), "", GetEnvironment())...)
+}
+
+func load(v reflect.Value, prefix string, e Environment) []error {
+	var errs []error
+
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			// Fields without an env tag are either nested structs or ignored
+			switch {
+			case fv.Kind() == reflect.Struct:
+				errs = append(errs, load(fv, prefix+field.Tag.Get("prefix"), e)...)
+			case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct:
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				errs = append(errs, load(fv.Elem(), prefix+field.Tag.Get("prefix"), e)...)
+			}
+			continue
+		}
+
+		key := prefix + name
+		str, file, err := lookupFile(key)
+		def, hasDefault := tagDefault(field, e)
+		required := field.Tag.Get("required") == "true"
+		declare(Var{
+			Name:        key,
+			Type:        fv.Type().String(),
+			Description: field.Tag.Get("desc"),
+			Default:     def,
+			Required:    required,
+			Secret:      file || field.Tag.Get("secret") == "true",
+			Value:       cmp.Or(str, def),
+			FromDefault: str == "" && hasDefault,
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if str == "" {
+			if !hasDefault {
+				if required {
+					errs = append(errs, errors.New("missing env variable: "+key))
+				}
+				continue
+			}
+			str = def
+		}
+
+		opts := defaultSplitOptions
+		if sep, ok := field.Tag.Lookup("sep"); ok {
+			opts.sep = sep
+		}
+		if kvSep, ok := field.Tag.Lookup("kvsep"); ok {
+			opts.kvSep = kvSep
+		}
+
+		if err := parse(key, str, fv, opts); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := validateField(key, field, fv, opts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
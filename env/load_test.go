@@ -0,0 +1,88 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Load(t *testing.T) {
+	type config struct {
+		Port     int     `env:"LOAD_PORT" default:"8080"`
+		Name     string  `env:"LOAD_NAME" required:"true"`
+		Ratio    float64 `env:"LOAD_RATIO"`
+		Ignored  string
+		internal string `env:"LOAD_INTERNAL"`
+		DB       struct {
+			Host string `env:"HOST" required:"true"`
+			Port uint16 `env:"PORT" default:"5432"`
+		} `prefix:"LOAD_DB_"`
+		Cache *struct {
+			Enabled bool `env:"ENABLED"`
+		} `prefix:"LOAD_CACHE_"`
+	}
+
+	t.Setenv("LOAD_NAME", "service")
+	t.Setenv("LOAD_INTERNAL", "internal")
+	t.Setenv("LOAD_DB_HOST", "localhost")
+	t.Setenv("LOAD_CACHE_ENABLED", "true")
+
+	var cfg config
+	if err := Load(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("got port %v, expected 8080", cfg.Port)
+	}
+	if cfg.Name != "service" {
+		t.Errorf("got name %v, expected service", cfg.Name)
+	}
+	if cfg.Ratio != 0 {
+		t.Errorf("got ratio %v, expected 0", cfg.Ratio)
+	}
+	if cfg.internal != "" {
+		t.Errorf("unexported field was set: %v", cfg.internal)
+	}
+	if cfg.DB.Host != "localhost" || cfg.DB.Port != 5432 {
+		t.Errorf("got db %+v, expected localhost:5432", cfg.DB)
+	}
+	if cfg.Cache == nil || !cfg.Cache.Enabled {
+		t.Errorf("got cache %+v, expected enabled", cfg.Cache)
+	}
+}
+
+func Test_Load_Errors(t *testing.T) {
+	type config struct {
+		Missing  string `env:"LOAD_ERR_MISSING" required:"true"`
+		Invalid  int    `env:"LOAD_ERR_INVALID"`
+		Default  int8   `env:"LOAD_ERR_DEFAULT" default:"1000"`
+		Optional string `env:"LOAD_ERR_OPTIONAL"`
+		Nested   struct {
+			Missing bool `env:"MISSING" required:"true"`
+		} `prefix:"LOAD_ERR_NESTED_"`
+	}
+
+	t.Setenv("LOAD_ERR_INVALID", "abc")
+
+	var cfg config
+	err := Load(&cfg)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+	for _, want := range []string{
+		"missing env variable: LOAD_ERR_MISSING",
+		"invalid int: LOAD_ERR_INVALID",
+		"invalid int8: LOAD_ERR_DEFAULT",
+		"missing env variable: LOAD_ERR_NESTED_MISSING",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "LOAD_ERR_OPTIONAL") {
+		t.Errorf("error %q should not contain optional variable", err)
+	}
+
+	if err := Load(cfg); err == nil {
+		t.Error("expected error for non-pointer but got none")
+	}
+}
@@ -0,0 +1,111 @@
+package env
+
+import (
+	"encoding"
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	urlType             = reflect.TypeFor[*url.URL]()
+	ipNetType           = reflect.TypeFor[*net.IPNet]()
+	udpAddrType         = reflect.TypeFor[*net.UDPAddr]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// parse parses str into v based on its type or kind, env is only used in error messages
+// and opts is only used for slices and maps
+func parse(env, str string, v reflect.Value, opts splitOptions) error {
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case urlType:
+		u, err := url.Parse(str)
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.Set(reflect.ValueOf(u))
+		return nil
+	case ipNetType:
+		_, ipNet, err := net.ParseCIDR(str)
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.Set(reflect.ValueOf(ipNet))
+		return nil
+	case udpAddrType:
+		addr, err := net.ResolveUDPAddr("udp", str)
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.Set(reflect.ValueOf(addr))
+		return nil
+	}
+
+	// time.Time, net.IP, netip.Addr, netip.Prefix, slog.Level, etc.
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str)); err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		ptr := reflect.New(v.Type().Elem())
+		if err := parse(env, str, ptr.Elem(), opts); err != nil {
+			return err
+		}
+		v.Set(ptr)
+	case reflect.Slice:
+		return parseSlice(env, str, v, opts)
+	case reflect.Map:
+		return parseMap(env, str, v, opts)
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		i64, err := strconv.ParseInt(str, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.SetInt(i64)
+	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uintptr:
+		u64, err := strconv.ParseUint(str, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.SetUint(u64)
+	case reflect.Float64, reflect.Float32:
+		f64, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.SetFloat(f64)
+	case reflect.Complex128, reflect.Complex64:
+		c128, err := strconv.ParseComplex(str, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid " + v.Type().String() + ": " + env)
+		}
+		v.SetComplex(c128)
+	default:
+		return errors.New("unsupported type: " + v.Type().String())
+	}
+
+	return nil
+}
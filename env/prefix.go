@@ -2,11 +2,14 @@ package env
 
 import (
 	"os"
+	"slices"
 	"strings"
 )
 
 // RemovePrefix resets environment variables with a certain prefix without the prefix
 // (e.g. added by the cloud provider)
+//
+// Deprecated: RemovePrefix rewrites the process environment globally and cannot be undone, use WithPrefix instead.
 func RemovePrefix(prefix string) {
 	for _, env := range os.Environ() {
 		pairs := strings.SplitN(env, "=", 2)
@@ -16,3 +19,93 @@ func RemovePrefix(prefix string) {
 		}
 	}
 }
+
+// PrefixSource is a view of a source that resolves a key through prefixes (e.g. APP_PORT for PORT)
+type PrefixSource struct {
+	src      Source
+	prefixes []string
+}
+
+// WithPrefix returns a prefixed view of the active sources, see Prefixed.
+// Use it with SetSources to scope it and to undo it:
+//
+//	restore := env.SetSources(env.WithPrefix("APP_", "CLOUD_"))
+//	defer restore()
+func WithPrefix(prefixes ...string) *PrefixSource {
+	return Prefixed(activeSource(), prefixes...)
+}
+
+// Prefixed returns a view of src that resolves a key as prefix+key for each of the prefixes in order
+// and as the key itself only if none of them is set. The source itself is not changed.
+func Prefixed(src Source, prefixes ...string) *PrefixSource {
+	return &PrefixSource{
+		src:      src,
+		prefixes: prefixes,
+	}
+}
+
+func (s *PrefixSource) Lookup(key string) (string, bool) {
+	for _, prefix := range s.prefixes {
+		if val, ok := s.src.Lookup(prefix + key); ok && val != "" {
+			return val, true
+		}
+	}
+	return s.src.Lookup(key)
+}
+
+// Keys returns the keys of the source and the keys with any of the prefixes stripped
+func (s *PrefixSource) Keys() []string {
+	keys := s.src.Keys()
+	for _, key := range s.src.Keys() {
+		for _, prefix := range s.prefixes {
+			if stripped, ok := strings.CutPrefix(key, prefix); ok && stripped != "" {
+				keys = append(keys, stripped)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// Collision is a key that is set more than once in a prefixed view
+type Collision struct {
+	// Key is the key as it is looked up (without prefix)
+	Key string
+	// Used is the key whose value is used (with prefix)
+	Used string
+	// Shadowed are the keys whose values are hidden by Used
+	Shadowed []string
+}
+
+func (c Collision) String() string {
+	return c.Key + ": " + c.Used + " shadows " + strings.Join(c.Shadowed, ", ")
+}
+
+// Collisions returns the keys whose value with a prefix shadows the value with a lower priority prefix or without prefix
+func (s *PrefixSource) Collisions() []Collision {
+	var collisions []Collision
+	for _, key := range s.Keys() {
+		var set []string
+		for _, candidate := range append(prefixed(s.prefixes, key), key) {
+			if val, ok := s.src.Lookup(candidate); ok && val != "" {
+				set = append(set, candidate)
+			}
+		}
+		if len(set) > 1 && set[0] != key {
+			collisions = append(collisions, Collision{
+				Key:      key,
+				Used:     set[0],
+				Shadowed: set[1:],
+			})
+		}
+	}
+	return collisions
+}
+
+func prefixed(prefixes []string, key string) []string {
+	keys := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		keys[i] = prefix + key
+	}
+	return keys
+}
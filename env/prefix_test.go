@@ -0,0 +1,46 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_WithPrefix(t *testing.T) {
+	src := Map{
+		"APP_PORT":   "8080",
+		"CLOUD_PORT": "9090",
+		"PORT":       "80",
+		"CLOUD_HOST": "cloud",
+		"APP_NAME":   "app",
+		"DEBUG":      "true",
+	}
+	defer SetSources(src)()
+
+	view := WithPrefix("APP_", "CLOUD_")
+	restore := SetSources(view)
+
+	for key, want := range map[string]string{
+		"PORT":     "8080",
+		"HOST":     "cloud",
+		"NAME":     "app",
+		"DEBUG":    "true",
+		"APP_NAME": "app",
+	} {
+		if got, err := GetOrFail[string](key); err != nil || got != want {
+			t.Errorf("got %q (%v) for %s, expected %q", got, err, key, want)
+		}
+	}
+
+	want := []Collision{{Key: "PORT", Used: "APP_PORT", Shadowed: []string{"CLOUD_PORT", "PORT"}}}
+	if got := view.Collisions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got collisions %v, expected %v", got, want)
+	}
+
+	restore()
+	if got, _ := GetOrFail[string]("PORT"); got != "80" {
+		t.Errorf("got %q after restore, expected 80", got)
+	}
+	if _, ok := src.Lookup("HOST"); ok {
+		t.Error("source was changed by the prefixed view")
+	}
+}
@@ -0,0 +1,108 @@
+package env
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Source provides configuration values by key (e.g. the OS environment or a .env file)
+type Source interface {
+	// Lookup returns the value of the key and whether it is set
+	Lookup(key string) (string, bool)
+	// Keys returns all keys set in the source
+	Keys() []string
+}
+
+var (
+	activeMu sync.RWMutex
+	active   Source = OS()
+)
+
+// SetSources replaces the sources used by the getters and Load (by default only the OS environment).
+// The sources are layered in the given order, so a key set in a source shadows the same key in later sources.
+// It returns a function that restores the previous sources (e.g. for tests).
+//
+// Example (environment variables override the .env file):
+//
+//	dotEnv, err := env.DotEnvFile(".env")
+//	...
+//	env.SetSources(env.OS(), dotEnv)
+func SetSources(sources ...Source) (restore func()) {
+	activeMu.Lock()
+	prev := active
+	active = Sources(sources)
+	activeMu.Unlock()
+
+	return func() {
+		activeMu.Lock()
+		active = prev
+		activeMu.Unlock()
+	}
+}
+
+func activeSource() Source {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return active
+}
+
+// Sources layers multiple sources, earlier sources have priority over later ones.
+// Empty values are treated as not set, so they do not shadow values of later sources.
+type Sources []Source
+
+func (s Sources) Lookup(key string) (string, bool) {
+	for _, src := range s {
+		if val, ok := src.Lookup(key); ok && val != "" {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+func (s Sources) Keys() []string {
+	var keys []string
+	for _, src := range s {
+		keys = append(keys, src.Keys()...)
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+type osSource struct{}
+
+// OS returns the source of the process environment
+func OS() Source {
+	return osSource{}
+}
+
+func (osSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (osSource) Keys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, env := range environ {
+		key, _, _ := strings.Cut(env, "=")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Map is an in-memory source (e.g. for tests or hard-coded overrides)
+type Map map[string]string
+
+func (m Map) Lookup(key string) (string, bool) {
+	val, ok := m[key]
+	return val, ok
+}
+
+func (m Map) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,191 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileSource is a source backed by a file (.env, JSON or YAML)
+type FileSource struct {
+	path   string
+	decode func([]byte) (Map, error)
+
+	mu     sync.RWMutex
+	values Map
+}
+
+// DotEnvFile returns a source with the variables of a .env file.
+// Supported are KEY=VALUE lines with an optional "export " prefix, single and double quoted values and # comments.
+func DotEnvFile(path string) (*FileSource, error) {
+	return newFileSource(path, decodeDotEnv)
+}
+
+// JSONFile returns a source with the values of a JSON file.
+// Nested objects are flattened into upper-case keys joined by "_" (e.g. {"db": {"host": "x"}} is DB_HOST=x)
+// and arrays of scalars are joined by "," (e.g. {"hosts": ["a", "b"]} is HOSTS=a,b).
+func JSONFile(path string) (*FileSource, error) {
+	return newFileSource(path, decodeJSON)
+}
+
+// YAMLFile returns a source with the values of a YAML file, flattened like in JSONFile
+func YAMLFile(path string) (*FileSource, error) {
+	return newFileSource(path, decodeYAML)
+}
+
+func newFileSource(path string, decode func([]byte) (Map, error)) (*FileSource, error) {
+	s := &FileSource{
+		path:   path,
+		decode: decode,
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload re-reads the file, the previous values are kept if it fails
+func (s *FileSource) Reload() error {
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", s.path, err)
+	}
+	values, err := s.decode(content)
+	if err != nil {
+		return fmt.Errorf("error decoding %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	s.values = values
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Path returns the path of the file
+func (s *FileSource) Path() string {
+	return s.path
+}
+
+func (s *FileSource) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values.Lookup(key)
+}
+
+func (s *FileSource) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values.Keys()
+}
+
+func decodeDotEnv(content []byte) (Map, error) {
+	values := Map{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid line %d", i)
+		}
+		val = strings.TrimSpace(val)
+
+		switch {
+		case strings.HasPrefix(val, `"`):
+			end := strings.LastIndex(val, `"`)
+			if end == 0 {
+				return nil, fmt.Errorf("unterminated quote in line %d", i)
+			}
+			unquoted, err := strconv.Unquote(val[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value in line %d", i)
+			}
+			val = unquoted
+		case strings.HasPrefix(val, "'"):
+			end := strings.LastIndex(val, "'")
+			if end == 0 {
+				return nil, fmt.Errorf("unterminated quote in line %d", i)
+			}
+			val = val[1:end]
+		default:
+			if comment := strings.Index(val, " #"); comment >= 0 {
+				val = strings.TrimSpace(val[:comment])
+			}
+		}
+
+		values[key] = val
+	}
+	return values, scanner.Err()
+}
+
+func decodeJSON(content []byte) (Map, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var tree map[string]any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	values := Map{}
+	flatten(values, "", tree)
+	return values, nil
+}
+
+func decodeYAML(content []byte) (Map, error) {
+	var tree map[string]any
+	if err := yaml.Unmarshal(content, &tree); err != nil {
+		return nil, err
+	}
+	values := Map{}
+	flatten(values, "", tree)
+	return values, nil
+}
+
+// flatten adds the scalars of a decoded JSON or YAML tree to values
+func flatten(values Map, prefix string, node any) {
+	switch node := node.(type) {
+	case map[string]any:
+		for key, child := range node {
+			flatten(values, joinKey(prefix, key), child)
+		}
+	case []any:
+		scalars := make([]string, 0, len(node))
+		for i, child := range node {
+			switch child.(type) {
+			case map[string]any, []any:
+				flatten(values, joinKey(prefix, strconv.Itoa(i)), child)
+			case nil:
+			default:
+				scalars = append(scalars, fmt.Sprint(child))
+			}
+		}
+		if len(scalars) > 0 {
+			values[prefix] = strings.Join(scalars, defaultSplitOptions.sep)
+		}
+	case nil:
+	default:
+		values[prefix] = fmt.Sprint(node)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	key = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
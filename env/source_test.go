@@ -0,0 +1,122 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_SetSources(t *testing.T) {
+	t.Setenv("SOURCE_OS", "os")
+	restore := SetSources(
+		Map{"SOURCE_SHADOWED": "first", "SOURCE_EMPTY": ""},
+		Map{"SOURCE_SHADOWED": "second", "SOURCE_EMPTY": "second", "SOURCE_ONLY_SECOND": "2"},
+	)
+
+	if got, err := GetOrFail[string]("SOURCE_SHADOWED"); err != nil || got != "first" {
+		t.Errorf("got %q (%v), expected first", got, err)
+	}
+	if got, err := GetOrFail[string]("SOURCE_EMPTY"); err != nil || got != "second" {
+		t.Errorf("got %q (%v), expected second", got, err)
+	}
+	if got, err := GetOrFail[int]("SOURCE_ONLY_SECOND"); err != nil || got != 2 {
+		t.Errorf("got %v (%v), expected 2", got, err)
+	}
+	if _, err := GetOrFail[string]("SOURCE_OS"); err == nil {
+		t.Error("expected error for variable outside of sources but got none")
+	}
+
+	restore()
+	if got, err := GetOrFail[string]("SOURCE_OS"); err != nil || got != "os" {
+		t.Errorf("got %q (%v), expected os", got, err)
+	}
+}
+
+func Test_FileSources(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		open    func(string) (*FileSource, error)
+		want    Map
+	}{
+		{
+			name: "dotenv",
+			file: ".env",
+			content: `# comment
+export HOST=localhost
+PORT = 8080 # inline comment
+DOUBLE="a \"quoted\"\nvalue" # comment
+SINGLE='#not a comment'
+EMPTY=
+`,
+			open: DotEnvFile,
+			want: Map{
+				"HOST":   "localhost",
+				"PORT":   "8080",
+				"DOUBLE": "a \"quoted\"\nvalue",
+				"SINGLE": "#not a comment",
+				"EMPTY":  "",
+			},
+		},
+		{
+			name:    "json",
+			file:    "config.json",
+			content: `{"port": 8080, "debug": true, "db": {"host": "localhost", "max-conns": 10}, "hosts": ["a", "b"], "servers": [{"name": "x"}], "none": null}`,
+			open:    JSONFile,
+			want: Map{
+				"PORT":           "8080",
+				"DEBUG":          "true",
+				"DB_HOST":        "localhost",
+				"DB_MAX_CONNS":   "10",
+				"HOSTS":          "a,b",
+				"SERVERS_0_NAME": "x",
+			},
+		},
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `port: 8080
+ratio: 0.5
+db:
+  host: localhost
+hosts:
+  - a
+  - b
+`,
+			open: YAMLFile,
+			want: Map{
+				"PORT":    "8080",
+				"RATIO":   "0.5",
+				"DB_HOST": "localhost",
+				"HOSTS":   "a,b",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			src, err := tt.open(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := Map{}
+			for _, key := range src.Keys() {
+				got[key], _ = src.Lookup(key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, expected %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DotEnvFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing file but got none")
+	}
+}
@@ -0,0 +1,165 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type splitOptions struct {
+	sep       string
+	kvSep     string
+	trimSpace bool
+}
+
+var defaultSplitOptions = splitOptions{
+	sep:       ",",
+	kvSep:     "=",
+	trimSpace: true,
+}
+
+// SplitOption configures how slice and map environment variables are split
+type SplitOption func(*splitOptions)
+
+// WithSeparator sets the separator between slice elements or map entries (default ",")
+func WithSeparator(sep string) SplitOption {
+	return func(o *splitOptions) {
+		o.sep = sep
+	}
+}
+
+// WithKeyValueSeparator sets the separator between the key and the value of map entries (default "=")
+func WithKeyValueSeparator(sep string) SplitOption {
+	return func(o *splitOptions) {
+		o.kvSep = sep
+	}
+}
+
+// WithoutTrimSpace keeps leading and trailing whitespace of elements, keys and values
+func WithoutTrimSpace() SplitOption {
+	return func(o *splitOptions) {
+		o.trimSpace = false
+	}
+}
+
+func newSplitOptions(opts []SplitOption) splitOptions {
+	o := defaultSplitOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// GetSliceOrFail returns the elements of the environment variable (e.g. A,B,C) or returns an error if it is not set.
+// Empty elements are skipped. Each element is parsed like in GetOrFail.
+func GetSliceOrFail[T any](env string, opts ...SplitOption) ([]T, error) {
+	str, file, err := lookupFile(env)
+	declare(Var{
+		Name:     env,
+		Type:     reflect.TypeFor[[]T]().String(),
+		Required: true,
+		Secret:   file,
+		Value:    str,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if str == "" {
+		return nil, errors.New("missing env variable: " + env)
+	}
+
+	var val []T
+	if err := parse(env, str, reflect.ValueOf(&val).Elem(), newSplitOptions(opts)); err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// GetMapOrFail returns the entries of the environment variable (e.g. k1=v1,k2=v2) or returns an error if it is not set.
+// Empty entries are skipped. Each key and value is parsed like in GetOrFail.
+func GetMapOrFail[K comparable, V any](env string, opts ...SplitOption) (map[K]V, error) {
+	str, file, err := lookupFile(env)
+	declare(Var{
+		Name:     env,
+		Type:     reflect.TypeFor[map[K]V]().String(),
+		Required: true,
+		Secret:   file,
+		Value:    str,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if str == "" {
+		return nil, errors.New("missing env variable: " + env)
+	}
+
+	var val map[K]V
+	if err := parse(env, str, reflect.ValueOf(&val).Elem(), newSplitOptions(opts)); err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+func (o splitOptions) split(str string) []string {
+	var parts []string
+	for _, part := range strings.Split(str, o.sep) {
+		if o.trimSpace {
+			part = strings.TrimSpace(part)
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func parseSlice(env, str string, v reflect.Value, opts splitOptions) error {
+	parts := opts.split(str)
+	slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+	var errs []error
+	for i, part := range parts {
+		if err := parse(env+"["+strconv.Itoa(i)+"]", part, slice.Index(i), opts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	v.Set(slice)
+	return nil
+}
+
+func parseMap(env, str string, v reflect.Value, opts splitOptions) error {
+	parts := opts.split(str)
+	m := reflect.MakeMapWithSize(v.Type(), len(parts))
+	var errs []error
+	for _, part := range parts {
+		k, val, ok := strings.Cut(part, opts.kvSep)
+		if opts.trimSpace {
+			k, val = strings.TrimSpace(k), strings.TrimSpace(val)
+		}
+		if !ok || k == "" {
+			errs = append(errs, errors.New("invalid map entry: "+env))
+			continue
+		}
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := parse(env+"["+k+"]", k, key, opts); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := parse(env+"["+k+"]", val, elem, opts); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.SetMapIndex(key, elem)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	v.Set(m)
+	return nil
+}
@@ -0,0 +1,73 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_GetSliceOrFail(t *testing.T) {
+	t.Setenv("SLICE_STRINGS", "a, b ,,c")
+	if got, err := GetSliceOrFail[string]("SLICE_STRINGS"); err != nil || !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("got %v (%v), expected [a b c]", got, err)
+	}
+	if got, err := GetSliceOrFail[string]("SLICE_STRINGS", WithoutTrimSpace()); err != nil || !reflect.DeepEqual(got, []string{"a", " b ", "c"}) {
+		t.Errorf("got %q (%v), expected [a \" b \" c]", got, err)
+	}
+
+	t.Setenv("SLICE_DURATIONS", "1s;2m")
+	if got, err := GetSliceOrFail[time.Duration]("SLICE_DURATIONS", WithSeparator(";")); err != nil || !reflect.DeepEqual(got, []time.Duration{time.Second, 2 * time.Minute}) {
+		t.Errorf("got %v (%v), expected [1s 2m0s]", got, err)
+	}
+
+	t.Setenv("SLICE_INVALID", "1,a,3")
+	if _, err := GetSliceOrFail[int]("SLICE_INVALID"); err == nil || err.Error() != "invalid int: SLICE_INVALID[1]" {
+		t.Errorf("got %v, expected invalid int: SLICE_INVALID[1]", err)
+	}
+
+	if _, err := GetSliceOrFail[int]("SLICE_MISSING"); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func Test_GetMapOrFail(t *testing.T) {
+	t.Setenv("MAP_INTS", "a=1, b = 2")
+	if got, err := GetMapOrFail[string, int]("MAP_INTS"); err != nil || !reflect.DeepEqual(got, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("got %v (%v), expected map[a:1 b:2]", got, err)
+	}
+
+	t.Setenv("MAP_SEPARATORS", "k1:v1;k2:v2")
+	if got, err := GetMapOrFail[string, string]("MAP_SEPARATORS", WithSeparator(";"), WithKeyValueSeparator(":")); err != nil || !reflect.DeepEqual(got, map[string]string{"k1": "v1", "k2": "v2"}) {
+		t.Errorf("got %v (%v), expected map[k1:v1 k2:v2]", got, err)
+	}
+
+	t.Setenv("MAP_INVALID", "a=1,b")
+	if _, err := GetMapOrFail[string, int]("MAP_INVALID"); err == nil || err.Error() != "invalid map entry: MAP_INVALID" {
+		t.Errorf("got %v, expected invalid map entry: MAP_INVALID", err)
+	}
+}
+
+func Test_Load_Split(t *testing.T) {
+	type config struct {
+		Hosts  []string          `env:"SPLIT_HOSTS"`
+		Ports  []uint16          `env:"SPLIT_PORTS" sep:" "`
+		Labels map[string]string `env:"SPLIT_LABELS" sep:";" kvsep:":"`
+	}
+
+	t.Setenv("SPLIT_HOSTS", "a,b")
+	t.Setenv("SPLIT_PORTS", "80 443")
+	t.Setenv("SPLIT_LABELS", "env:prod;team:core")
+
+	var cfg config
+	if err := Load(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := config{
+		Hosts:  []string{"a", "b"},
+		Ports:  []uint16{80, 443},
+		Labels: map[string]string{"env": "prod", "team": "core"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v, expected %+v", cfg, want)
+	}
+}
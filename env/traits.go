@@ -0,0 +1,78 @@
+package env
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Traits describe the behavior of an environment.
+// Consumers should decide on traits instead of comparing environments, so custom environments work as well.
+type Traits struct {
+	// ProductionLike environments serve real users or real data (e.g. prod or canary)
+	ProductionLike bool
+	// VerboseLogging enables debug logs including their source
+	VerboseLogging bool
+	// TextLogging uses human-readable text logs instead of JSON logs
+	TextLogging bool
+	// TrustedErrors exposes error details to all callers
+	TrustedErrors bool
+}
+
+var (
+	environmentsMu sync.RWMutex
+	environments   = []Environment{EnvironmentProd, EnvironmentStaging, EnvironmentDev, EnvironmentLocal}
+	traits         = map[Environment]Traits{
+		EnvironmentProd:    {ProductionLike: true},
+		EnvironmentStaging: {VerboseLogging: true, TrustedErrors: true},
+		EnvironmentDev:     {VerboseLogging: true, TrustedErrors: true},
+		EnvironmentLocal:   {VerboseLogging: true, TextLogging: true, TrustedErrors: true},
+	}
+)
+
+// RegisterEnvironment registers a custom environment (e.g. qa, canary or sandbox) or replaces the traits of a known one
+func RegisterEnvironment(e Environment, t Traits) {
+	environmentsMu.Lock()
+	defer environmentsMu.Unlock()
+	if _, ok := traits[e]; !ok {
+		environments = append(environments, e)
+	}
+	traits[e] = t
+}
+
+// Environments returns the known environments in order of registration
+func Environments() []Environment {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+	return append([]Environment(nil), environments...)
+}
+
+// Traits returns the traits of the environment, an unknown environment has the traits of EnvironmentProd
+func (e Environment) Traits() Traits {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+	if t, ok := traits[e]; ok {
+		return t
+	}
+	return traits[EnvironmentProd]
+}
+
+// PerEnvironment returns the default of the current environment or fallback if there is none.
+// Example:
+//
+//	level, err := env.GetWithFallback("LOG_LEVEL", env.PerEnvironment(slog.LevelInfo, map[env.Environment]slog.Level{
+//		env.EnvironmentLocal: slog.LevelDebug,
+//	}))
+func PerEnvironment[T any](fallback T, defaults map[Environment]T) T {
+	if def, ok := defaults[GetEnvironment()]; ok {
+		return def
+	}
+	return fallback
+}
+
+// tagDefault returns the default_<environment> tag of the field for the environment or its default tag otherwise
+func tagDefault(field reflect.StructField, e Environment) (string, bool) {
+	if def, ok := field.Tag.Lookup("default_" + e.String()); ok {
+		return def, true
+	}
+	return field.Tag.Lookup("default")
+}
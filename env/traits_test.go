@@ -0,0 +1,41 @@
+package env
+
+import (
+	"testing"
+)
+
+func Test_RegisterEnvironment(t *testing.T) {
+	qa := Environment("qa")
+	RegisterEnvironment(qa, Traits{VerboseLogging: true})
+
+	defer SetSources(Map{"ENVIRONMENT": "qa"})()
+
+	e, err := LookupEnvironment()
+	if err != nil || e != qa {
+		t.Fatalf("got %v (%v), expected qa", e, err)
+	}
+	if got := e.Traits(); got != (Traits{VerboseLogging: true}) {
+		t.Errorf("got traits %+v, expected verbose logging", got)
+	}
+	if got := Environment("unknown").Traits(); got != EnvironmentProd.Traits() {
+		t.Errorf("got traits %+v for unknown environment, expected prod traits", got)
+	}
+
+	if got := PerEnvironment(1, map[Environment]int{qa: 2, EnvironmentProd: 3}); got != 2 {
+		t.Errorf("got %v, expected 2", got)
+	}
+	if got := PerEnvironment(1, map[Environment]int{EnvironmentProd: 3}); got != 1 {
+		t.Errorf("got %v, expected 1", got)
+	}
+
+	var cfg struct {
+		Level   string `env:"TRAITS_LEVEL" default:"info" default_qa:"debug"`
+		Timeout string `env:"TRAITS_TIMEOUT" default:"1s" default_prod:"5s"`
+	}
+	if err := Load(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Level != "debug" || cfg.Timeout != "1s" {
+		t.Errorf("got %+v, expected debug and 1s", cfg)
+	}
+}
@@ -0,0 +1,206 @@
+package env
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Validator validates the parsed value of an environment variable, any func(T) error can be used as a custom Validator
+type Validator[T any] func(T) error
+
+// Min validates that the value is at least min
+func Min[T cmp.Ordered](min T) Validator[T] {
+	return func(val T) error {
+		if val < min {
+			return fmt.Errorf("must be at least %v", min)
+		}
+		return nil
+	}
+}
+
+// Max validates that the value is at most max
+func Max[T cmp.Ordered](max T) Validator[T] {
+	return func(val T) error {
+		if val > max {
+			return fmt.Errorf("must be at most %v", max)
+		}
+		return nil
+	}
+}
+
+// OneOf validates that the value is one of the given values
+func OneOf[T comparable](values ...T) Validator[T] {
+	return func(val T) error {
+		for _, v := range values {
+			if val == v {
+				return nil
+			}
+		}
+		strs := make([]string, len(values))
+		for i, v := range values {
+			strs[i] = fmt.Sprint(v)
+		}
+		return errors.New("must be one of " + strings.Join(strs, ", "))
+	}
+}
+
+// Regex validates that the value matches the pattern, it panics if the pattern is invalid
+func Regex(pattern string) Validator[string] {
+	re := regexp.MustCompile(pattern)
+	return func(val string) error {
+		if !re.MatchString(val) {
+			return errors.New("must match " + pattern)
+		}
+		return nil
+	}
+}
+
+// NonEmpty validates that the value is not empty or only whitespace
+func NonEmpty() Validator[string] {
+	return func(val string) error {
+		if strings.TrimSpace(val) == "" {
+			return errors.New("must not be empty")
+		}
+		return nil
+	}
+}
+
+func validate[T any](env string, val T, validators []Validator[T]) error {
+	for _, validator := range validators {
+		if err := validator(val); err != nil {
+			return fmt.Errorf("invalid env variable %s: %w", env, err)
+		}
+	}
+	return nil
+}
+
+var (
+	customValidatorsMu sync.RWMutex
+	customValidators   = map[string]func(any) error{}
+)
+
+// RegisterValidator registers a custom validator which can be used by its name in the validate tag of Load.
+// The validator is called with the value of the field (e.g. an int for an int field).
+func RegisterValidator(name string, validator func(any) error) {
+	customValidatorsMu.Lock()
+	defer customValidatorsMu.Unlock()
+	customValidators[name] = validator
+}
+
+// validateField validates v using the validate and regex tags of field
+func validateField(key string, field reflect.StructField, v reflect.Value, opts splitOptions) error {
+	if pattern, ok := field.Tag.Lookup("regex"); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex for env variable %s: %w", key, err)
+		}
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("invalid regex for env variable %s: unsupported type %s", key, v.Type())
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Errorf("invalid env variable %s: must match %s", key, pattern)
+		}
+	}
+
+	rules, ok := field.Tag.Lookup("validate")
+	if !ok {
+		return nil
+	}
+	for rule := range strings.SplitSeq(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if err := validateRule(key, name, arg, v, opts); err != nil {
+			return fmt.Errorf("invalid env variable %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func validateRule(key, name, arg string, v reflect.Value, opts splitOptions) error {
+	switch name {
+	case "":
+		return nil
+	case "nonempty":
+		switch v.Kind() {
+		case reflect.String:
+			if strings.TrimSpace(v.String()) == "" {
+				return errors.New("must not be empty")
+			}
+		case reflect.Slice, reflect.Map:
+			if v.Len() == 0 {
+				return errors.New("must not be empty")
+			}
+		default:
+			if v.IsZero() {
+				return errors.New("must not be empty")
+			}
+		}
+		return nil
+	case "min", "max":
+		c, length, err := compareBound(key, arg, v, opts)
+		if err != nil {
+			return err
+		}
+		switch {
+		case name == "min" && c < 0 && length:
+			return errors.New("must have a length of at least " + arg)
+		case name == "min" && c < 0:
+			return errors.New("must be at least " + arg)
+		case name == "max" && c > 0 && length:
+			return errors.New("must have a length of at most " + arg)
+		case name == "max" && c > 0:
+			return errors.New("must be at most " + arg)
+		}
+		return nil
+	case "oneof":
+		for option := range strings.FieldsSeq(arg) {
+			val := reflect.New(v.Type()).Elem()
+			if err := parse(key, option, val, opts); err != nil {
+				return err
+			}
+			if val.Equal(v) {
+				return nil
+			}
+		}
+		return errors.New("must be one of " + strings.Join(strings.Fields(arg), ", "))
+	}
+
+	customValidatorsMu.RLock()
+	validator, ok := customValidators[name]
+	customValidatorsMu.RUnlock()
+	if !ok {
+		return errors.New("unknown validator " + name)
+	}
+	return validator(v.Interface())
+}
+
+// compareBound compares v to the bound in arg, for strings, slices and maps their length is compared
+func compareBound(key, arg string, v reflect.Value, opts splitOptions) (c int, length bool, err error) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		bound, err := strconv.Atoi(arg)
+		if err != nil {
+			return 0, true, errors.New("invalid bound " + arg)
+		}
+		return cmp.Compare(v.Len(), bound), true, nil
+	}
+
+	bound := reflect.New(v.Type()).Elem()
+	if err := parse(key, arg, bound, opts); err != nil {
+		return 0, false, errors.New("invalid bound " + arg)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		return cmp.Compare(v.Int(), bound.Int()), false, nil
+	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uintptr:
+		return cmp.Compare(v.Uint(), bound.Uint()), false, nil
+	case reflect.Float64, reflect.Float32:
+		return cmp.Compare(v.Float(), bound.Float()), false, nil
+	}
+	return 0, false, errors.New("unsupported type " + v.Type().String() + " for min and max")
+}
@@ -0,0 +1,167 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_GetOrFail_Validators(t *testing.T) {
+	restore := SetSources(Map{
+		"PORT":  "0",
+		"LEVEL": "trace",
+		"NAME":  "Service",
+		"RATIO": "0.5",
+	})
+	defer restore()
+
+	tests := []struct {
+		name string
+		get  func() error
+		want string
+	}{
+		{
+			name: "min",
+			get: func() error {
+				_, err := GetOrFail("PORT", Min(1), Max(65535))
+				return err
+			},
+			want: "invalid env variable PORT: must be at least 1",
+		},
+		{
+			name: "max",
+			get: func() error {
+				_, err := GetOrFail("RATIO", Max(0.25))
+				return err
+			},
+			want: "invalid env variable RATIO: must be at most 0.25",
+		},
+		{
+			name: "oneof",
+			get: func() error {
+				_, err := GetOrFail("LEVEL", OneOf("debug", "info"))
+				return err
+			},
+			want: "invalid env variable LEVEL: must be one of debug, info",
+		},
+		{
+			name: "regex",
+			get: func() error {
+				_, err := GetOrFail("NAME", Regex("^[a-z]+$"))
+				return err
+			},
+			want: "invalid env variable NAME: must match ^[a-z]+$",
+		},
+		{
+			name: "custom",
+			get: func() error {
+				_, err := GetWithFallback("NAME", "", func(s string) error {
+					if !strings.HasPrefix(s, "svc-") {
+						return errors.New("must start with svc-")
+					}
+					return nil
+				})
+				return err
+			},
+			want: "invalid env variable NAME: must start with svc-",
+		},
+		{
+			name: "valid",
+			get: func() error {
+				_, err := GetOrFail("NAME", NonEmpty(), Regex("^[A-Z]"))
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.get()
+			if tt.want == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if tt.want != "" && (err == nil || err.Error() != tt.want) {
+				t.Errorf("got %v, expected %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Load_Validate(t *testing.T) {
+	RegisterValidator("even", func(v any) error {
+		if v.(int)%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+
+	type config struct {
+		Port    int           `env:"PORT" validate:"min=1,max=65535"`
+		Timeout time.Duration `env:"TIMEOUT" default:"1h" validate:"max=1m"`
+		Level   string        `env:"LEVEL" validate:"oneof=debug info warn"`
+		Name    string        `env:"NAME" validate:"min=3" regex:"^[a-z]+$"`
+		Hosts   []string      `env:"HOSTS" validate:"nonempty"`
+		Count   int           `env:"COUNT" validate:"even"`
+		Valid   int           `env:"VALID" default:"4" validate:"even,min=2"`
+	}
+
+	restore := SetSources(Map{
+		"PORT":  "70000",
+		"LEVEL": "trace",
+		"NAME":  "AB",
+		"HOSTS": " , ",
+		"COUNT": "3",
+	})
+	defer restore()
+
+	var cfg config
+	err := Load(&cfg)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+	for _, want := range []string{
+		"invalid env variable PORT: must be at most 65535",
+		"invalid env variable TIMEOUT: must be at most 1m",
+		"invalid env variable LEVEL: must be one of debug, info, warn",
+		"invalid env variable NAME: must match ^[a-z]+$",
+		"invalid env variable HOSTS: must not be empty",
+		"invalid env variable COUNT: must be even",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "VALID") {
+		t.Errorf("error %q should not contain valid variable", err)
+	}
+}
+
+func Test_LookupEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Environment
+		wantErr bool
+	}{
+		{name: "prod", value: "prod", want: EnvironmentProd},
+		{name: "unset", value: "", want: EnvironmentLocal},
+		{name: "local", value: "local", want: EnvironmentLocal},
+		{name: "typo", value: "prdo", want: EnvironmentProd, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer SetSources(Map{"ENVIRONMENT": tt.value})()
+			got, err := LookupEnvironment()
+			if got != tt.want {
+				t.Errorf("got %v, expected %v", got, tt.want)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, expected error: %v", err, tt.wantErr)
+			}
+			if got := GetEnvironment(); got != tt.want {
+				t.Errorf("got %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
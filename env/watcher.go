@@ -0,0 +1,201 @@
+package env
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pedramktb/go-base-lib/lifecycle"
+)
+
+// Watcher reloads file sources on SIGHUP or when their files change and notifies subscribers of changed values
+type Watcher struct {
+	sources []*FileSource
+
+	mu          sync.Mutex
+	subscribers []*subscriber
+	onError     func(error)
+	// pending are the calls of subscribers and OnError in the order their values were recorded,
+	// they are run by one goroutine at a time (see deliver)
+	pending    []func()
+	delivering bool
+}
+
+type subscriber struct {
+	env  string
+	last string
+	// prepare parses the value and returns the call of the subscriber's function
+	prepare func(str string) (func(), error)
+}
+
+// NewWatcher creates a Watcher for the given sources (which should also be set with SetSources).
+// The files are checked for changes every interval (or only on SIGHUP if interval is zero).
+// The watcher stops when ctx is done and delays the shutdown of a lifecycle.Context until it has stopped.
+func NewWatcher(ctx context.Context, interval time.Duration, sources ...*FileSource) *Watcher {
+	w := &Watcher{
+		sources: sources,
+	}
+
+	done, err := lifecycle.RegisterCloser(ctx)
+	if err != nil {
+		done = func() {}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer done()
+		defer signal.Stop(hup)
+
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		stats := w.stat()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				w.Reload()
+				stats = w.stat()
+			case <-tick:
+				if newStats := w.stat(); !reflect.DeepEqual(stats, newStats) {
+					w.Reload()
+					stats = newStats
+				}
+			}
+		}
+	}()
+
+	return w
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+func (w *Watcher) stat() []fileStat {
+	stats := make([]fileStat, len(w.sources))
+	for i, src := range w.sources {
+		if info, err := os.Stat(src.Path()); err == nil {
+			stats[i] = fileStat{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stats
+}
+
+// OnError sets a function that is called with errors of reloads and subscribers (which are ignored by default)
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = fn
+}
+
+// Reload reloads the sources and notifies the subscribers of changed values.
+// Subscribers and OnError are called after the watcher is unlocked, so they may use the watcher themselves.
+// The calls of overlapping reloads are run one at a time in the order the values were recorded,
+// so a reload during the calls of another one (e.g. from a subscriber) returns before its calls are run.
+func (w *Watcher) Reload() {
+	w.mu.Lock()
+	var errs []error
+	for _, src := range w.sources {
+		if err := src.Reload(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var notify []func()
+	for _, sub := range w.subscribers {
+		str, err := lookup(sub.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if str == sub.last {
+			continue
+		}
+		fn, err := sub.prepare(str)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sub.last = str
+		notify = append(notify, fn)
+	}
+	if onError := w.onError; onError != nil {
+		for _, err := range errs {
+			w.pending = append(w.pending, func() { onError(err) })
+		}
+	}
+	w.pending = append(w.pending, notify...)
+	w.mu.Unlock()
+
+	w.deliver()
+}
+
+// deliver runs the pending calls unless another goroutine is already running them
+func (w *Watcher) deliver() {
+	w.mu.Lock()
+	if w.delivering {
+		w.mu.Unlock()
+		return
+	}
+	w.delivering = true
+	for len(w.pending) > 0 {
+		fn := w.pending[0]
+		w.pending = w.pending[1:]
+		w.mu.Unlock()
+		fn()
+		w.mu.Lock()
+	}
+	w.delivering = false
+	w.mu.Unlock()
+}
+
+// Subscribe calls fn with the current value of the environment variable and again whenever it changes after a reload.
+// The value is parsed like in GetOrFail, an unset environment variable results in the zero value
+// (e.g. use a pointer type to tell unset values apart). Invalid values are reported to OnError and skipped.
+// The first call is run before Subscribe returns unless the calls of a reload are running (e.g. when a subscriber subscribes),
+// then it is run after them.
+func Subscribe[T any](w *Watcher, env string, fn func(T)) error {
+	sub := &subscriber{
+		env: env,
+		prepare: func(str string) (func(), error) {
+			var val T
+			if str != "" {
+				if err := parse(env, str, reflect.ValueOf(&val).Elem(), defaultSplitOptions); err != nil {
+					return nil, err
+				}
+			}
+			return func() { fn(val) }, nil
+		},
+	}
+
+	w.mu.Lock()
+	str, err := lookup(env)
+	if err != nil {
+		w.mu.Unlock()
+		return err
+	}
+	notify, err := sub.prepare(str)
+	if err != nil {
+		w.mu.Unlock()
+		return err
+	}
+	sub.last = str
+	w.subscribers = append(w.subscribers, sub)
+	w.pending = append(w.pending, notify)
+	w.mu.Unlock()
+
+	w.deliver()
+	return nil
+}
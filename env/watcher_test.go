@@ -0,0 +1,174 @@
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_Watcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("WATCH_PORT=8080\nWATCH_NAME=a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	src, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer SetSources(src)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher(ctx, 0, src)
+
+	ports := make(chan int, 2)
+	if err := Subscribe(w, "WATCH_PORT", func(port int) { ports <- port }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := <-ports; got != 8080 {
+		t.Errorf("got %v, expected 8080", got)
+	}
+	names := make(chan string, 2)
+	if err := Subscribe(w, "WATCH_NAME", func(name string) { names <- name }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-names
+
+	errs := make(chan error, 2)
+	w.OnError(func(err error) { errs <- err })
+
+	if err := os.WriteFile(path, []byte("WATCH_PORT=9090\nWATCH_NAME=a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-ports:
+		if got != 9090 {
+			t.Errorf("got %v, expected 9090", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not notified")
+	}
+	if len(names) != 0 {
+		t.Error("subscriber of an unchanged value was notified")
+	}
+
+	if err := os.WriteFile(path, []byte("WATCH_PORT=invalid\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w.Reload()
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Fatal("invalid int error was not reported")
+	}
+	if got := <-names; got != "" {
+		t.Errorf("got %q, expected empty value for unset variable", got)
+	}
+}
+
+func Test_Watcher_ReentrantSubscriber(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("REENTRANT_A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	src, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer SetSources(src)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher(ctx, 0, src)
+
+	// A subscriber that uses the watcher must not deadlock a reload
+	subscribed := make(chan string, 1)
+	if err := Subscribe(w, "REENTRANT_A", func(a string) {
+		if a != "2" {
+			return
+		}
+		w.OnError(func(error) {})
+		if err := Subscribe(w, "REENTRANT_B", func(b string) { subscribed <- b }); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("REENTRANT_A=2\nREENTRANT_B=b\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		w.Reload()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reload deadlocked")
+	}
+	if got := <-subscribed; got != "b" {
+		t.Errorf("got %v, expected b", got)
+	}
+}
+
+func Test_Watcher_OverlappingReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("OVERLAP=0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	src, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer SetSources(src)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher(ctx, 0, src)
+
+	var (
+		mu   sync.Mutex
+		last int
+	)
+	if err := Subscribe(w, "OVERLAP", func(v int) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		last = v
+		mu.Unlock()
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The subscriber ends up with the last recorded value even if reloads overlap
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		if err := os.WriteFile(path, []byte("OVERLAP="+strconv.Itoa(i)+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(2)
+		go func() { defer wg.Done(); w.Reload() }()
+		go func() { defer wg.Done(); w.Reload() }()
+	}
+	wg.Wait()
+	w.Reload()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if last != 20 {
+		t.Errorf("got %v, expected 20", last)
+	}
+}
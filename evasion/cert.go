@@ -1,15 +1,21 @@
 package evasion
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
+	"fmt"
 	"math/big"
 	unsafeRand "math/rand"
 	"net"
+	"slices"
 	"strings"
 	"time"
 )
@@ -25,43 +31,353 @@ func randomString(n int) string {
 	return sb.String()
 }
 
-func NewCert() (tls.Certificate, error) {
-	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+// KeyType is the type of the key of a certificate
+type KeyType int
+
+const (
+	KeyTypeP256 KeyType = iota
+	KeyTypeP384
+	KeyTypeEd25519
+	KeyTypeRSA2048
+	KeyTypeRSA4096
+)
+
+// Provider models the certificates of a common certificate provider
+type Provider int
+
+const (
+	// ProviderNone uses a random Organization (the default)
+	ProviderNone Provider = iota
+	// ProviderLetsEncrypt issues 90 day certificates with only a CommonName (issuer "R11", "Let's Encrypt")
+	ProviderLetsEncrypt
+	// ProviderGoogle issues 90 day certificates with only a CommonName (issuer "WR1", "Google Trust Services")
+	ProviderGoogle
+	// ProviderCloudflare issues 1 year origin certificates (issuer "Cloudflare Origin SSL Certificate Authority")
+	ProviderCloudflare
+)
+
+type certOptions struct {
+	dnsNames    []string
+	ipAddresses []net.IP
+	keyType     KeyType
+	notBefore   time.Time
+	validity    time.Duration
+	subject     *pkix.Name
+	provider    Provider
+	ca          *tls.Certificate
+}
+
+// CertOption configures NewCert and NewCA
+type CertOption func(*certOptions)
+
+func newCertOptions(opts []CertOption) certOptions {
+	var o certOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithDNSNames sets the DNS names of the certificate (the first one is the CommonName of provider subjects)
+func WithDNSNames(names ...string) CertOption {
+	return func(o *certOptions) {
+		o.dnsNames = append(o.dnsNames, names...)
+	}
+}
+
+// WithIPAddresses sets the IP addresses of the certificate instead of 127.0.0.1 and 0.0.0.0
+func WithIPAddresses(ips ...net.IP) CertOption {
+	return func(o *certOptions) {
+		o.ipAddresses = append(o.ipAddresses, ips...)
+	}
+}
+
+// WithKeyType sets the key type of the certificate (KeyTypeP256 by default)
+func WithKeyType(keyType KeyType) CertOption {
+	return func(o *certOptions) {
+		o.keyType = keyType
+	}
+}
+
+// WithValidity sets the validity window of the certificate, a zero notBefore is backdated by a random
+// duration of up to 30 days (at most a tenth of the validity) like a certificate that was issued some time ago
+func WithValidity(notBefore time.Time, validity time.Duration) CertOption {
+	return func(o *certOptions) {
+		o.notBefore = notBefore
+		o.validity = validity
+	}
+}
+
+// WithSubject sets the subject of the certificate
+func WithSubject(subject pkix.Name) CertOption {
+	return func(o *certOptions) {
+		o.subject = &subject
+	}
+}
+
+// WithProvider models the subject, issuer and validity of the certificate on a common provider.
+// Without WithCA the certificate is signed by a new CA with the issuer of the provider (see NewCA),
+// which is added to the chain like the intermediate CA of the provider.
+func WithProvider(provider Provider) CertOption {
+	return func(o *certOptions) {
+		o.provider = provider
+	}
+}
+
+// WithCA signs the certificate with a CA (see NewCA) instead of self-signing it, the CA is added to the chain
+func WithCA(ca tls.Certificate) CertOption {
+	return func(o *certOptions) {
+		o.ca = &ca
+	}
+}
+
+// NewCert creates a TLS certificate. Without options it creates a self-signed P-256 certificate
+// for 127.0.0.1 and 0.0.0.0 with a random Organization that is valid for 24 years.
+func NewCert(opts ...CertOption) (tls.Certificate, error) {
+	o := newCertOptions(opts)
+
+	notBefore, notAfter := o.validityWindow()
+
+	subject := pkix.Name{
+		Organization: []string{randomString(unsafeRand.Intn(16))},
+	}
+	if o.provider != ProviderNone {
+		subject = o.provider.subject(o.dnsNames)
+	}
+	if o.subject != nil {
+		subject = *o.subject
+	}
+
+	if o.provider != ProviderNone && o.ca == nil {
+		// The intermediate CA was issued before the certificate
+		ca, err := NewCA(WithProvider(o.provider), WithValidity(notBefore.Add(-365*24*time.Hour), 5*365*24*time.Hour))
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("error creating provider ca: %w", err)
+		}
+		o.ca = &ca
+	}
+
+	priv, err := generateKey(o.keyType)
 	if err != nil {
 		return tls.Certificate{}, err
 	}
 
-	notBefore := time.Now()
-	notAfter := notBefore.Add(24 * 365 * 24 * time.Hour)
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, ok := priv.(*rsa.PrivateKey); ok {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
 
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	template := &x509.Certificate{
+		Subject:               subject,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              o.dnsNames,
+		IPAddresses:           o.certIPAddresses(),
+	}
+
+	return createCert(template, priv, o.ca)
+}
+
+// NewCA creates a local CA to sign certificates with WithCA. The provider option sets the subject
+// of an intermediate CA of that provider (e.g. "R11" of Let's Encrypt) and the CA option creates an intermediate CA.
+// Without a validity the CA is valid for 5 years.
+func NewCA(opts ...CertOption) (tls.Certificate, error) {
+	o := newCertOptions(opts)
+	if o.validity == 0 {
+		o.validity = 5 * 365 * 24 * time.Hour
+	}
+	notBefore, notAfter := o.validityWindow()
+
+	subject := pkix.Name{
+		Organization: []string{randomString(1 + unsafeRand.Intn(16))},
+		CommonName:   randomString(1+unsafeRand.Intn(16)) + " CA",
+	}
+	if o.provider != ProviderNone {
+		subject = o.provider.issuer()
+	}
+	if o.subject != nil {
+		subject = *o.subject
+	}
+
+	priv, err := generateKey(o.keyType)
 	if err != nil {
 		return tls.Certificate{}, err
 	}
 
-	template := x509.Certificate{
-		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Organization: []string{randomString(unsafeRand.Intn(16))},
-		},
+	template := &x509.Certificate{
+		Subject:               subject,
 		NotBefore:             notBefore,
 		NotAfter:              notAfter,
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
 		BasicConstraintsValid: true,
-		IPAddresses: []net.IP{
+		IsCA:                  true,
+	}
+
+	return createCert(template, priv, o.ca)
+}
+
+// certIPAddresses returns the IP addresses of the options or 127.0.0.1 and 0.0.0.0 if there are no SANs
+func (o certOptions) certIPAddresses() []net.IP {
+	if len(o.dnsNames) == 0 && len(o.ipAddresses) == 0 {
+		return []net.IP{
 			net.ParseIP("127.0.0.1"),
 			net.ParseIP("0.0.0.0"),
-		},
+		}
+	}
+	return o.ipAddresses
+}
+
+// matches returns true if the certificate has the SANs and key type of the options
+func (o certOptions) matches(leaf *x509.Certificate) bool {
+	return slices.Equal(leaf.DNSNames, o.dnsNames) &&
+		slices.EqualFunc(leaf.IPAddresses, o.certIPAddresses(), net.IP.Equal) &&
+		o.keyType.matches(leaf.PublicKey)
+}
+
+// validityWindow returns the validity of the options, of the provider or 24 years starting now by default
+func (o certOptions) validityWindow() (time.Time, time.Time) {
+	validity := o.validity
+	if validity == 0 {
+		validity = o.provider.validity()
+	}
+	if validity == 0 {
+		notBefore := time.Now()
+		return notBefore, notBefore.Add(24 * 365 * 24 * time.Hour)
+	}
+
+	notBefore := o.notBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+		// The backdate is limited to a tenth of the validity, so short-lived certificates are valid now
+		if maxBackdate := min(30*24*time.Hour, validity/10); maxBackdate > 0 {
+			notBefore = notBefore.Add(-time.Duration(unsafeRand.Int63n(int64(maxBackdate))))
+		}
+		notBefore = notBefore.Truncate(time.Second)
+	}
+	return notBefore, notBefore.Add(validity)
+}
+
+func (p Provider) validity() time.Duration {
+	switch p {
+	case ProviderLetsEncrypt, ProviderGoogle:
+		return 90 * 24 * time.Hour
+	case ProviderCloudflare:
+		return 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func (p Provider) subject(dnsNames []string) pkix.Name {
+	var commonName string
+	if len(dnsNames) > 0 {
+		commonName = dnsNames[0]
+	}
+	switch p {
+	case ProviderCloudflare:
+		return pkix.Name{
+			Organization:       []string{"CloudFlare, Inc."},
+			OrganizationalUnit: []string{"CloudFlare Origin CA"},
+			CommonName:         "CloudFlare Origin Certificate",
+		}
+	default:
+		return pkix.Name{CommonName: commonName}
+	}
+}
+
+func (p Provider) issuer() pkix.Name {
+	switch p {
+	case ProviderLetsEncrypt:
+		return pkix.Name{Country: []string{"US"}, Organization: []string{"Let's Encrypt"}, CommonName: "R11"}
+	case ProviderGoogle:
+		return pkix.Name{Country: []string{"US"}, Organization: []string{"Google Trust Services"}, CommonName: "WR1"}
+	case ProviderCloudflare:
+		return pkix.Name{
+			Country:            []string{"US"},
+			Province:           []string{"California"},
+			Locality:           []string{"San Francisco"},
+			Organization:       []string{"CloudFlare, Inc."},
+			OrganizationalUnit: []string{"CloudFlare Origin SSL Certificate Authority"},
+		}
+	default:
+		return pkix.Name{}
+	}
+}
+
+// matches returns true if the public key has the key type
+func (k KeyType) matches(publicKey crypto.PublicKey) bool {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return (k == KeyTypeP256 && key.Curve == elliptic.P256()) || (k == KeyTypeP384 && key.Curve == elliptic.P384())
+	case ed25519.PublicKey:
+		return k == KeyTypeEd25519
+	case *rsa.PublicKey:
+		return (k == KeyTypeRSA2048 && key.N.BitLen() == 2048) || (k == KeyTypeRSA4096 && key.N.BitLen() == 4096)
+	default:
+		return false
+	}
+}
+
+func generateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case KeyTypeP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyTypeEd25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	case KeyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %d", keyType)
+	}
+}
+
+// createCert signs the template with the CA or self-signs it if ca is nil
+func createCert(template *x509.Certificate, priv crypto.Signer, ca *tls.Certificate) (tls.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template.SerialNumber = serialNumber
+
+	parent, signer := template, priv
+	var chain [][]byte
+	if ca != nil {
+		if len(ca.Certificate) == 0 {
+			return tls.Certificate{}, errors.New("ca has no certificate")
+		}
+		parent, err = x509.ParseCertificate(ca.Certificate[0])
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("error parsing ca certificate: %w", err)
+		}
+		caSigner, ok := ca.PrivateKey.(crypto.Signer)
+		if !ok {
+			return tls.Certificate{}, errors.New("ca private key is not a crypto.Signer")
+		}
+		signer = caSigner
+		chain = ca.Certificate
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, parent, priv.Public(), signer)
+	if err != nil {
+		return tls.Certificate{}, err
 	}
 
-	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	leaf, err := x509.ParseCertificate(derBytes)
 	if err != nil {
 		return tls.Certificate{}, err
 	}
 
 	return tls.Certificate{
-		Certificate: [][]byte{derBytes},
+		Certificate: append([][]byte{derBytes}, chain...),
 		PrivateKey:  priv,
+		Leaf:        leaf,
 	}, nil
 }
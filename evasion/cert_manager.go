@@ -0,0 +1,276 @@
+package evasion
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pedramktb/go-base-lib/lifecycle"
+)
+
+// minRenewDelay is the minimum delay of a scheduled renewal, so certificates that expire soon after
+// their creation (e.g. with a fixed notBefore in the past) are not renewed in a loop
+const minRenewDelay = time.Minute
+
+// CertManager serves certificates created by NewCert and regenerates them before they expire.
+// The previous certificate is kept until it expires and served to clients that do not support the current one
+// (e.g. after a change of the key type).
+type CertManager struct {
+	opts        []CertOption
+	certOptions certOptions
+	dir         string
+	renewBefore time.Duration
+
+	mu       sync.RWMutex
+	current  *tls.Certificate
+	previous *tls.Certificate
+	onError  func(error)
+	renew    chan struct{}
+}
+
+// NewCertManager creates a CertManager that creates certificates with the options.
+// The certificates are renewed renewBefore they expire (a third of their validity if zero),
+// renewBefore has to be shorter than the validity of the certificates.
+// If dir is not empty the certificates are persisted in it and loaded on start, so restarts keep the fingerprint.
+// A loaded certificate with other SANs or another key type than the options or an invalid file is rotated.
+// The manager stops when ctx is done and delays the shutdown of a lifecycle.Context until it has stopped.
+func NewCertManager(ctx context.Context, dir string, renewBefore time.Duration, opts ...CertOption) (*CertManager, error) {
+	o := newCertOptions(opts)
+	notBefore, notAfter := o.validityWindow()
+	if renewBefore < 0 || renewBefore >= notAfter.Sub(notBefore) {
+		return nil, fmt.Errorf("renewBefore %s must be between 0 and the certificate validity %s", renewBefore, notAfter.Sub(notBefore))
+	}
+
+	m := &CertManager{
+		opts:        opts,
+		certOptions: o,
+		dir:         dir,
+		renewBefore: renewBefore,
+		renew:       make(chan struct{}, 1),
+	}
+
+	if dir != "" {
+		if err := m.load(); err != nil {
+			return nil, err
+		}
+	}
+	if m.current == nil || !o.matches(m.current.Leaf) || !time.Now().Before(m.renewAt()) {
+		if err := m.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	done, err := lifecycle.RegisterCloser(ctx)
+	if err != nil {
+		done = func() {}
+	}
+
+	go func() {
+		defer done()
+
+		timer := time.NewTimer(m.renewDelay())
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.renew:
+			case <-timer.C:
+				if err := m.Rotate(); err != nil {
+					m.error(err)
+					timer.Reset(time.Minute)
+					continue
+				}
+			}
+			timer.Reset(m.renewDelay())
+		}
+	}()
+
+	return m, nil
+}
+
+// OnError sets a function that is called with errors of scheduled rotations (which are retried every minute)
+func (m *CertManager) OnError(fn func(error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onError = fn
+}
+
+func (m *CertManager) error(err error) {
+	m.mu.RLock()
+	onError := m.onError
+	m.mu.RUnlock()
+	if onError != nil {
+		onError(err)
+	}
+}
+
+// Rotate creates a new certificate and keeps the current one as the previous certificate
+func (m *CertManager) Rotate() error {
+	cert, err := NewCert(m.opts...)
+	if err != nil {
+		return fmt.Errorf("error creating certificate: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.current
+	if m.dir != "" {
+		if previous != nil {
+			if err := saveCert(m.dir, "previous", previous); err != nil {
+				return err
+			}
+		}
+		if err := saveCert(m.dir, "current", &cert); err != nil {
+			return err
+		}
+	}
+	m.previous, m.current = previous, &cert
+
+	// Reschedule the renewal of a running manager
+	select {
+	case m.renew <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Certificate returns the current certificate
+func (m *CertManager) Certificate() *tls.Certificate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
+
+// GetCertificate returns the current certificate or the previous one if the client only supports it
+// and it has not expired, it can be used as tls.Config.GetCertificate
+func (m *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if hello.SupportsCertificate(m.current) != nil && m.previous != nil &&
+		time.Now().Before(m.previous.Leaf.NotAfter) && hello.SupportsCertificate(m.previous) == nil {
+		return m.previous, nil
+	}
+	return m.current, nil
+}
+
+// TLSConfig returns a tls.Config that serves the certificates of the manager
+func (m *CertManager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: m.GetCertificate,
+	}
+}
+
+// renewAt returns the time the current certificate is renewed
+func (m *CertManager) renewAt() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	leaf := m.current.Leaf
+	renewBefore := m.renewBefore
+	if renewBefore == 0 {
+		renewBefore = leaf.NotAfter.Sub(leaf.NotBefore) / 3
+	}
+	return leaf.NotAfter.Add(-renewBefore)
+}
+
+// renewDelay returns the delay until the current certificate is renewed, at least minRenewDelay
+func (m *CertManager) renewDelay() time.Duration {
+	return max(time.Until(m.renewAt()), minRenewDelay)
+}
+
+// load loads the persisted certificates. Missing files and files that can not be parsed
+// (e.g. with a key that does not match the certificate) are ignored, so a new certificate is created instead.
+func (m *CertManager) load() error {
+	current, err := loadCert(m.dir, "current")
+	if err != nil {
+		return err
+	}
+	previous, err := loadCert(m.dir, "previous")
+	if err != nil {
+		return err
+	}
+	// An interrupted rotation can leave the current certificate in both files
+	if current != nil && previous != nil && slices.EqualFunc(current.Certificate, previous.Certificate, bytes.Equal) {
+		previous = nil
+	}
+	m.current, m.previous = current, previous
+	return nil
+}
+
+// loadCert loads <name>.pem from dir and returns nil if it does not exist or can not be parsed
+func loadCert(dir, name string) (*tls.Certificate, error) {
+	pemBytes, err := os.ReadFile(filepath.Join(dir, name+".pem"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading certificate: %w", err)
+	}
+
+	cert, err := tls.X509KeyPair(pemBytes, pemBytes)
+	if err != nil {
+		return nil, nil
+	}
+	return &cert, nil
+}
+
+// saveCert writes the private key and the certificate chain to <name>.pem in dir,
+// the file is replaced at once so the key always matches the certificate
+func saveCert(dir, name string, cert *tls.Certificate) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("error creating certificate directory: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("error marshaling certificate key: %w", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	for _, der := range cert.Certificate {
+		pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, name+".pem"), pemBytes, 0o600); err != nil {
+		return fmt.Errorf("error writing certificate: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes the data to a temporary file and renames it to path,
+// so path contains either the old or the new data if the process is interrupted
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,119 @@
+package evasion
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CertManager_Rotate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, err := NewCertManager(ctx, "", 0, WithDNSNames("example.com"), WithValidity(time.Time{}, time.Hour))
+	require.NoError(t, err)
+	first := m.Certificate()
+	require.NotNil(t, first)
+	assert.Equal(t, []string{"example.com"}, first.Leaf.DNSNames)
+
+	require.NoError(t, m.Rotate())
+	current := m.Certificate()
+	assert.NotEqual(t, first.Certificate[0], current.Certificate[0])
+
+	// Clients supporting the current certificate get it
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{
+		SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedVersions: []uint16{tls.VersionTLS13},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, current, cert)
+	assert.Equal(t, uint16(tls.VersionTLS12), m.TLSConfig().MinVersion)
+}
+
+func Test_CertManager_RenewBefore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := NewCertManager(ctx, "", time.Hour, WithValidity(time.Time{}, time.Hour))
+	assert.Error(t, err)
+	_, err = NewCertManager(ctx, "", -time.Minute, WithValidity(time.Time{}, time.Hour))
+	assert.Error(t, err)
+
+	// A certificate that has to be renewed right away is not renewed in a loop
+	m, err := NewCertManager(ctx, "", 30*time.Minute, WithValidity(time.Now().Add(-50*time.Minute), time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, minRenewDelay, m.renewDelay())
+}
+
+func Test_CertManager_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, err := NewCertManager(ctx, dir, 0, WithDNSNames("example.com"))
+	require.NoError(t, err)
+	first := m.Certificate()
+	require.NoError(t, m.Rotate())
+	current := m.Certificate()
+
+	info, err := os.Stat(filepath.Join(dir, "current.pem"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "only the certificate files are left in the directory")
+
+	// A restart loads the persisted certificates
+	m, err = NewCertManager(ctx, dir, 0, WithDNSNames("example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, current.Certificate, m.Certificate().Certificate)
+	assert.Equal(t, first.Certificate, m.previous.Certificate)
+
+	// Certificates with other SANs or another key type are rotated
+	m, err = NewCertManager(ctx, dir, 0, WithDNSNames("example.com", "www.example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, m.Certificate().Leaf.DNSNames)
+	assert.Equal(t, current.Certificate, m.previous.Certificate)
+
+	m, err = NewCertManager(ctx, dir, 0, WithDNSNames("example.com", "www.example.com"), WithKeyType(KeyTypeEd25519))
+	require.NoError(t, err)
+	assert.True(t, KeyTypeEd25519.matches(m.Certificate().Leaf.PublicKey))
+}
+
+func Test_CertManager_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A key that does not match the certificate is ignored and a new certificate is created
+	cert, err := NewCert()
+	require.NoError(t, err)
+	other, err := NewCert()
+	require.NoError(t, err)
+	require.NoError(t, saveCert(dir, "current", &tls.Certificate{Certificate: cert.Certificate, PrivateKey: other.PrivateKey}))
+	m, err := NewCertManager(ctx, dir, 0)
+	require.NoError(t, err)
+	current := m.Certificate()
+	assert.NotEqual(t, cert.Certificate, current.Certificate)
+	assert.Nil(t, m.previous)
+
+	// A file that can not be parsed is ignored as well
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.pem"), []byte("invalid"), 0o600))
+	m, err = NewCertManager(ctx, dir, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, current.Certificate, m.Certificate().Certificate)
+
+	// A previous certificate equal to the current one (an interrupted rotation) is dropped
+	current = m.Certificate()
+	require.NoError(t, saveCert(dir, "previous", current))
+	m, err = NewCertManager(ctx, dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, current.Certificate, m.Certificate().Certificate)
+	assert.Nil(t, m.previous)
+}
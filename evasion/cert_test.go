@@ -0,0 +1,153 @@
+package evasion
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewCert(t *testing.T) {
+	cert, err := NewCert()
+	require.NoError(t, err)
+	leaf := cert.Leaf
+
+	assert.Len(t, cert.Certificate, 1)
+	assert.Equal(t, leaf.Subject.String(), leaf.Issuer.String(), "the certificate is self-signed")
+	assert.NoError(t, leaf.CheckSignature(leaf.SignatureAlgorithm, leaf.RawTBSCertificate, leaf.Signature))
+	assert.IsType(t, &ecdsa.PrivateKey{}, cert.PrivateKey)
+	require.Len(t, leaf.IPAddresses, 2)
+	assert.True(t, leaf.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+	assert.True(t, leaf.IPAddresses[1].Equal(net.ParseIP("0.0.0.0")))
+	assert.True(t, leaf.NotAfter.After(time.Now().Add(20*365*24*time.Hour)))
+}
+
+func Test_NewCert_Validity(t *testing.T) {
+	for _, validity := range []time.Duration{time.Minute, time.Hour, 24 * time.Hour, 90 * 24 * time.Hour} {
+		t.Run(validity.String(), func(t *testing.T) {
+			for range 16 {
+				now := time.Now()
+				cert, err := NewCert(WithValidity(time.Time{}, validity))
+				require.NoError(t, err)
+				leaf := cert.Leaf
+
+				// now is within [NotBefore, NotAfter)
+				assert.False(t, now.Before(leaf.NotBefore.Add(-time.Second)))
+				assert.True(t, now.Before(leaf.NotAfter))
+				assert.Equal(t, validity, leaf.NotAfter.Sub(leaf.NotBefore))
+				assert.LessOrEqual(t, now.Sub(leaf.NotBefore), min(30*24*time.Hour, validity/10)+time.Second)
+			}
+		})
+	}
+
+	notBefore := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	cert, err := NewCert(WithValidity(notBefore, time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, notBefore, cert.Leaf.NotBefore.UTC())
+	assert.Equal(t, notBefore.Add(time.Hour), cert.Leaf.NotAfter.UTC())
+}
+
+func Test_NewCert_SANs(t *testing.T) {
+	cert, err := NewCert(
+		WithDNSNames("example.com", "www.example.com"),
+		WithIPAddresses(net.ParseIP("10.0.0.1")),
+		WithSubject(pkix.Name{CommonName: "subject"}),
+	)
+	require.NoError(t, err)
+	leaf := cert.Leaf
+
+	assert.Equal(t, []string{"example.com", "www.example.com"}, leaf.DNSNames)
+	require.Len(t, leaf.IPAddresses, 1)
+	assert.True(t, leaf.IPAddresses[0].Equal(net.ParseIP("10.0.0.1")))
+	assert.Equal(t, "subject", leaf.Subject.CommonName)
+	assert.NoError(t, leaf.VerifyHostname("www.example.com"))
+	assert.Error(t, leaf.VerifyHostname("other.com"))
+}
+
+func Test_NewCert_KeyTypes(t *testing.T) {
+	tests := []struct {
+		keyType KeyType
+		key     any
+	}{
+		{keyType: KeyTypeP256, key: &ecdsa.PrivateKey{}},
+		{keyType: KeyTypeP384, key: &ecdsa.PrivateKey{}},
+		{keyType: KeyTypeEd25519, key: ed25519.PrivateKey{}},
+		{keyType: KeyTypeRSA2048, key: &rsa.PrivateKey{}},
+	}
+
+	for _, tt := range tests {
+		cert, err := NewCert(WithKeyType(tt.keyType))
+		require.NoError(t, err)
+		assert.IsType(t, tt.key, cert.PrivateKey)
+		assert.Equal(t, cert.PrivateKey.(crypto.Signer).Public(), cert.Leaf.PublicKey)
+	}
+
+	_, err := NewCert(WithKeyType(KeyType(-1)))
+	assert.Error(t, err)
+}
+
+func Test_NewCert_CA(t *testing.T) {
+	root, err := NewCA()
+	require.NoError(t, err)
+	intermediate, err := NewCA(WithCA(root), WithProvider(ProviderLetsEncrypt))
+	require.NoError(t, err)
+	cert, err := NewCert(WithCA(intermediate), WithDNSNames("example.com"))
+	require.NoError(t, err)
+
+	assert.True(t, root.Leaf.IsCA)
+	assert.True(t, intermediate.Leaf.IsCA)
+	assert.Equal(t, "R11", cert.Leaf.Issuer.CommonName)
+	require.Len(t, cert.Certificate, 3, "the chain contains the intermediate and the root")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Leaf)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediate.Leaf)
+	_, err = cert.Leaf.Verify(x509.VerifyOptions{
+		DNSName:       "example.com",
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	assert.NoError(t, err)
+}
+
+func Test_NewCert_Provider(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		issuer   string
+		validity time.Duration
+	}{
+		{provider: ProviderLetsEncrypt, issuer: "R11", validity: 90 * 24 * time.Hour},
+		{provider: ProviderGoogle, issuer: "WR1", validity: 90 * 24 * time.Hour},
+		{provider: ProviderCloudflare, issuer: "CloudFlare Origin SSL Certificate Authority", validity: 365 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		cert, err := NewCert(WithProvider(tt.provider), WithDNSNames("example.com"))
+		require.NoError(t, err)
+		leaf := cert.Leaf
+
+		// The certificate is signed by a CA with the issuer of the provider
+		require.Len(t, cert.Certificate, 2)
+		ca, err := x509.ParseCertificate(cert.Certificate[1])
+		require.NoError(t, err)
+		assert.NoError(t, leaf.CheckSignatureFrom(ca))
+		assert.Equal(t, ca.Subject.String(), leaf.Issuer.String())
+		assert.NotEqual(t, leaf.Subject.String(), leaf.Issuer.String())
+		assert.Contains(t, leaf.Issuer.String(), tt.issuer)
+		assert.Equal(t, tt.validity, leaf.NotAfter.Sub(leaf.NotBefore))
+		assert.True(t, ca.NotBefore.Before(leaf.NotBefore))
+	}
+
+	cert, err := NewCert(WithProvider(ProviderLetsEncrypt), WithDNSNames("example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", cert.Leaf.Subject.CommonName)
+}
@@ -0,0 +1,64 @@
+package evasion
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Decoy imitates the default error pages of a web server
+type Decoy string
+
+const (
+	DecoyNone   Decoy = "none"
+	DecoyNginx  Decoy = "nginx"
+	DecoyApache Decoy = "apache"
+	DecoyCaddy  Decoy = "caddy"
+)
+
+func (d *Decoy) UnmarshalText(text []byte) error {
+	switch decoy := Decoy(text); decoy {
+	case "", DecoyNone, DecoyNginx, DecoyApache, DecoyCaddy:
+		*d = decoy
+		return nil
+	default:
+		return fmt.Errorf("unknown decoy: %s", text)
+	}
+}
+
+// apacheMessages are the paragraphs of Apache's default error pages
+var apacheMessages = map[int]string{
+	http.StatusBadRequest:          "Your browser sent a request that this server could not understand.<br />\n",
+	http.StatusUnauthorized:        "This server could not verify that you\nare authorized to access the document\nrequested.  Either you supplied the wrong\ncredentials (e.g., bad password), or your\nbrowser doesn't understand how to supply\nthe credentials required.",
+	http.StatusForbidden:           "You don't have permission to access this resource.",
+	http.StatusNotFound:            "The requested URL was not found on this server.",
+	http.StatusInternalServerError: "The server encountered an internal error or\nmisconfiguration and was unable to complete\nyour request.",
+	http.StatusNotImplemented:      "The requested method is not supported for current URL.<br />\n",
+	http.StatusBadGateway:          "The proxy server received an invalid\nresponse from an upstream server.<br />\n",
+	http.StatusServiceUnavailable:  "The server is temporarily unable to service your\nrequest due to maintenance downtime or capacity\nproblems. Please try again later.",
+}
+
+// Write writes the status code and the error page of the decoy
+func (d Decoy) Write(w http.ResponseWriter, code int) {
+	status := strconv.Itoa(code) + " " + http.StatusText(code)
+
+	switch d {
+	case DecoyNginx:
+		w.Header().Set("Server", "nginx")
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(code)
+		_, _ = fmt.Fprintf(w, "<html>\r\n<head><title>%s</title></head>\r\n<body>\r\n<center><h1>%s</h1></center>\r\n<hr><center>nginx</center>\r\n</body>\r\n</html>\r\n", status, status)
+	case DecoyApache:
+		w.Header().Set("Server", "Apache")
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.WriteHeader(code)
+		_, _ = fmt.Fprintf(w, "<!DOCTYPE HTML PUBLIC \"-//IETF//DTD HTML 2.0//EN\">\n<html><head>\n<title>%s</title>\n</head><body>\n<h1>%s</h1>\n<p>%s</p>\n</body></html>\n",
+			status, http.StatusText(code), apacheMessages[code])
+	case DecoyCaddy:
+		// Caddy answers errors with an empty body by default
+		w.Header().Set("Server", "Caddy")
+		w.WriteHeader(code)
+	default:
+		w.WriteHeader(code)
+	}
+}
@@ -3,6 +3,7 @@ package evasion
 import (
 	"errors"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/pedramktb/go-base-lib/env"
 	"github.com/pedramktb/go-base-lib/taggederror"
@@ -15,14 +16,41 @@ var evasiveError = taggederror.NewRoot(
 	http.StatusOK,
 )
 
+// environmentTraits caches the traits of the environment after the first successful lookup
+var environmentTraits atomic.Pointer[env.Traits]
+
+// trustedEnvironment returns true if the environment exposes error details to all callers.
+// The environment is looked up once, so the error path does not read the environment sources on every request.
+func trustedEnvironment() bool {
+	if traits := environmentTraits.Load(); traits != nil {
+		return traits.TrustedErrors
+	}
+	e, err := env.LookupEnvironment()
+	if err != nil {
+		return false
+	}
+	traits := e.Traits()
+	environmentTraits.Store(&traits)
+	return traits.TrustedErrors
+}
+
+// ErrorHandler handles errors of trusted callers and environments with taggederror.Handler (always with status code 200)
+// and answers untrusted callers according to the profile set by SetProfile or LoadProfile (DefaultProfile by default).
+// A caller is trusted if trusted is true or the request context is marked as trusted (see TrustMiddleware).
+// The environment is looked up on the first error, later changes of the environment are not used.
 func ErrorHandler(err error, trusted bool, w http.ResponseWriter, r *http.Request) {
-	if trusted || env.GetEnvironment() != env.EnvironmentProd {
+	if trusted || IsTrusted(r.Context()) || trustedEnvironment() {
 		trustedHandler(err, w, r)
 	} else {
-		w.WriteHeader(FailStatusCode)
+		currentProfile().Respond(w, r)
 	}
 }
 
+// Handler is ErrorHandler with the trust of the request context, it has the signature of taggederror.Handler
+func Handler(err error, w http.ResponseWriter, r *http.Request) {
+	ErrorHandler(err, false, w, r)
+}
+
 // trustedHandler uses evasiveError to handle errors
 func trustedHandler(err error, w http.ResponseWriter, r *http.Request) {
 	var taggedErr *taggederror.Error
@@ -0,0 +1,145 @@
+package evasion
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/pedramktb/go-base-lib/env"
+)
+
+// Profile defines how ErrorHandler answers untrusted callers
+type Profile struct {
+	// Code returns the status code of the response
+	Code func(r *http.Request) int
+	// Decoy imitates the error pages of a web server, DecoyNone sends an empty body
+	Decoy Decoy
+}
+
+// DefaultProfile answers with FailStatusCode and an empty body
+var DefaultProfile = Profile{Code: RandomCode()}
+
+var profile atomic.Pointer[Profile]
+
+// SetProfile sets the profile of ErrorHandler
+func SetProfile(p Profile) {
+	profile.Store(&p)
+}
+
+func currentProfile() Profile {
+	if p := profile.Load(); p != nil {
+		return *p
+	}
+	return DefaultProfile
+}
+
+// Respond writes the status code and decoy body of the profile
+func (p Profile) Respond(w http.ResponseWriter, r *http.Request) {
+	code := FailStatusCode
+	if p.Code != nil {
+		code = p.Code(r)
+	}
+	p.Decoy.Write(w, code)
+}
+
+// RandomCode returns FailStatusCode, which is random per process
+func RandomCode() func(r *http.Request) int {
+	return func(*http.Request) int {
+		return FailStatusCode
+	}
+}
+
+// FixedCode always returns the code (e.g. http.StatusNotFound to pretend nothing exists)
+func FixedCode(code int) func(r *http.Request) int {
+	return func(*http.Request) int {
+		return code
+	}
+}
+
+// RouteCode returns a failed status code that is stable per route (the pattern of http.ServeMux or the path)
+// and depends on the seed, so deployments with different seeds return different codes
+func RouteCode(seed string) func(r *http.Request) int {
+	return func(r *http.Request) int {
+		route := r.Pattern
+		if route == "" {
+			route = r.Method + " " + r.URL.Path
+		}
+		return seededCode(seed, route)
+	}
+}
+
+// ClientCode returns a failed status code that is stable per client IP and depends on the seed
+func ClientCode(seed string) func(r *http.Request) int {
+	return func(r *http.Request) int {
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		return seededCode(seed, client)
+	}
+}
+
+// seededCode picks one of the failed status codes by the HMAC of the key
+func seededCode(seed, key string) int {
+	mac := hmac.New(sha256.New, []byte(seed))
+	mac.Write([]byte(key))
+	return statusCodes[binary.BigEndian.Uint64(mac.Sum(nil))%uint64(len(statusCodes))]
+}
+
+// Config selects the Profile of ErrorHandler and can be filled from environment variables using env.Load
+type Config struct {
+	// Mode is one of random (FailStatusCode), fixed (StatusCode), route (RouteCode), client (ClientCode)
+	// or notfound (404 for everything)
+	Mode       string `env:"MODE" default:"random" validate:"oneof=random fixed route client notfound"`
+	StatusCode int    `env:"STATUS_CODE" default:"404" validate:"min=400,max=599"`
+	// Seed of the route and client modes, random per process if empty
+	Seed  string `env:"SEED" secret:"true"`
+	Decoy Decoy  `env:"DECOY" default:"none"`
+}
+
+// NewProfile creates the Profile selected by cfg
+func NewProfile(cfg Config) (Profile, error) {
+	seed := cfg.Seed
+	if seed == "" && (cfg.Mode == "route" || cfg.Mode == "client") {
+		seed = rand.Text()
+	}
+
+	p := Profile{Decoy: cfg.Decoy}
+	switch cfg.Mode {
+	case "", "random":
+		p.Code = RandomCode()
+	case "fixed":
+		p.Code = FixedCode(cfg.StatusCode)
+	case "route":
+		p.Code = RouteCode(seed)
+	case "client":
+		p.Code = ClientCode(seed)
+	case "notfound":
+		p.Code = FixedCode(http.StatusNotFound)
+	default:
+		return Profile{}, fmt.Errorf("unknown evasion mode: %s", cfg.Mode)
+	}
+	return p, nil
+}
+
+// LoadProfile sets the profile of ErrorHandler from the environment variables
+// EVASION_MODE, EVASION_STATUS_CODE, EVASION_SEED and EVASION_DECOY (see Config)
+func LoadProfile() error {
+	var cfg struct {
+		Evasion Config `prefix:"EVASION_"`
+	}
+	if err := env.Load(&cfg); err != nil {
+		return err
+	}
+	p, err := NewProfile(cfg.Evasion)
+	if err != nil {
+		return err
+	}
+	SetProfile(p)
+	return nil
+}
@@ -0,0 +1,189 @@
+package evasion
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestEnvironment sets the environment of ErrorHandler and looks it up again after the test
+func setTestEnvironment(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv("ENVIRONMENT", name)
+	environmentTraits.Store(nil)
+	t.Cleanup(func() { environmentTraits.Store(nil) })
+}
+
+// setTestProfile sets the profile of ErrorHandler and restores the default profile after the test
+func setTestProfile(t *testing.T, p Profile) {
+	t.Helper()
+	SetProfile(p)
+	t.Cleanup(func() { profile.Store(nil) })
+}
+
+func Test_RouteCode(t *testing.T) {
+	code := RouteCode("seed")
+
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	assert.Contains(t, statusCodes, code(req))
+	assert.Equal(t, code(req), code(httptest.NewRequest(http.MethodGet, "/a", nil)))
+
+	// The pattern of http.ServeMux is used instead of the path
+	mux := http.NewServeMux()
+	var codes []int
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		codes = append(codes, code(r))
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/2", nil))
+	require.Len(t, codes, 2)
+	assert.Equal(t, codes[0], codes[1])
+
+	// Other seeds result in other codes for some routes
+	otherCode := RouteCode("other seed")
+	differs := false
+	for i := range 32 {
+		req := httptest.NewRequest(http.MethodGet, "/"+strconv.Itoa(i), nil)
+		differs = differs || code(req) != otherCode(req)
+	}
+	assert.True(t, differs)
+}
+
+func Test_ClientCode(t *testing.T) {
+	code := ClientCode("seed")
+
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	other := httptest.NewRequest(http.MethodPost, "/b", nil)
+	other.RemoteAddr = "10.0.0.1:5678"
+	assert.Contains(t, statusCodes, code(req))
+	assert.Equal(t, code(req), code(other), "the code only depends on the client IP")
+
+	assert.Equal(t, http.StatusTeapot, FixedCode(http.StatusTeapot)(req))
+	assert.Equal(t, FailStatusCode, RandomCode()(req))
+}
+
+func Test_Decoy(t *testing.T) {
+	tests := []struct {
+		decoy  Decoy
+		server string
+		body   string
+	}{
+		{decoy: DecoyNone},
+		{decoy: DecoyNginx, server: "nginx", body: "<center><h1>404 Not Found</h1></center>"},
+		{decoy: DecoyApache, server: "Apache", body: "<p>The requested URL was not found on this server.</p>"},
+		{decoy: DecoyCaddy, server: "Caddy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.decoy), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.decoy.Write(rec, http.StatusNotFound)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, tt.server, rec.Header().Get("Server"))
+			if tt.body == "" {
+				assert.Empty(t, rec.Body.String())
+			} else {
+				assert.Contains(t, rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func Test_Decoy_UnmarshalText(t *testing.T) {
+	var decoy Decoy
+	require.NoError(t, decoy.UnmarshalText([]byte("nginx")))
+	assert.Equal(t, DecoyNginx, decoy)
+	require.NoError(t, decoy.UnmarshalText([]byte("")))
+	assert.Equal(t, Decoy(""), decoy)
+	assert.Error(t, decoy.UnmarshalText([]byte("iis")))
+}
+
+func Test_NewProfile(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	p, err := NewProfile(Config{Mode: "notfound", Decoy: DecoyNginx})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, p.Code(req))
+	assert.Equal(t, DecoyNginx, p.Decoy)
+
+	p, err = NewProfile(Config{Mode: "fixed", StatusCode: http.StatusForbidden})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, p.Code(req))
+
+	// The same seed results in the same codes
+	p, err = NewProfile(Config{Mode: "route", Seed: "seed"})
+	require.NoError(t, err)
+	assert.Equal(t, RouteCode("seed")(req), p.Code(req))
+
+	// A missing seed is random
+	p, err = NewProfile(Config{Mode: "client"})
+	require.NoError(t, err)
+	assert.Contains(t, statusCodes, p.Code(req))
+
+	_, err = NewProfile(Config{Mode: "unknown"})
+	assert.Error(t, err)
+}
+
+func Test_LoadProfile(t *testing.T) {
+	setTestEnvironment(t, "prod")
+	t.Setenv("EVASION_MODE", "fixed")
+	t.Setenv("EVASION_STATUS_CODE", "403")
+	t.Setenv("EVASION_DECOY", "nginx")
+	t.Cleanup(func() { profile.Store(nil) })
+	require.NoError(t, LoadProfile())
+
+	rec := httptest.NewRecorder()
+	Handler(errors.New("secret details"), rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "nginx", rec.Header().Get("Server"))
+	assert.Contains(t, rec.Body.String(), "<center><h1>403 Forbidden</h1></center>")
+	assert.NotContains(t, rec.Body.String(), "secret details")
+
+	t.Setenv("EVASION_DECOY", "iis")
+	assert.Error(t, LoadProfile())
+}
+
+func Test_ErrorHandler_Profile(t *testing.T) {
+	setTestEnvironment(t, "prod")
+	setTestProfile(t, Profile{Code: FixedCode(http.StatusNotFound), Decoy: DecoyApache})
+
+	rec := httptest.NewRecorder()
+	ErrorHandler(errors.New("secret details"), false, rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "Apache", rec.Header().Get("Server"))
+	assert.NotContains(t, rec.Body.String(), "secret details")
+
+	// Trusted callers get the error with status code 200
+	rec = httptest.NewRecorder()
+	ErrorHandler(errors.New("secret details"), true, rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Server"))
+}
+
+func Test_ErrorHandler_Environment(t *testing.T) {
+	setTestEnvironment(t, "dev")
+	setTestProfile(t, Profile{Code: FixedCode(http.StatusNotFound)})
+
+	rec := httptest.NewRecorder()
+	Handler(errors.New("details"), rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The environment is looked up once
+	t.Setenv("ENVIRONMENT", "prod")
+	rec = httptest.NewRecorder()
+	Handler(errors.New("details"), rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// An invalid environment is not trusted and not cached
+	setTestEnvironment(t, "prdo")
+	rec = httptest.NewRecorder()
+	Handler(errors.New("details"), rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Nil(t, environmentTraits.Load())
+}
@@ -0,0 +1,83 @@
+package evasion
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/pedramktb/go-base-lib/auth"
+)
+
+type trustedKey struct{}
+
+// WithTrusted returns a context that marks the request as trusted (or not) for ErrorHandler
+func WithTrusted(ctx context.Context, trusted bool) context.Context {
+	return context.WithValue(ctx, trustedKey{}, trusted)
+}
+
+// IsTrusted returns true if the context was marked as trusted by WithTrusted or TrustMiddleware
+func IsTrusted(ctx context.Context) bool {
+	trusted, _ := ctx.Value(trustedKey{}).(bool)
+	return trusted
+}
+
+// TrustSource decides if a request is trusted
+type TrustSource func(r *http.Request) bool
+
+// TrustMiddleware returns a http middleware that marks requests as trusted in their context
+// if any of the sources trusts them. Untrusted requests are passed on unchanged.
+func TrustMiddleware(sources ...TrustSource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, source := range sources {
+				if source(r) {
+					r = r.WithContext(WithTrusted(r.Context(), true))
+					break
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TrustSignature trusts requests with a valid signature (see auth.ED25519Signer.SignRequest).
+// The body of signed requests is read before the signature is verified, limited by verification.MaxBodySize.
+// The nonces of verification are not used, so the trust check does not consume the nonce
+// and a later auth.ED25519Verifier.Middleware with the same NonceStore still accepts the request.
+func TrustSignature(verifier *auth.ED25519Verifier, verification auth.HTTPVerification) TrustSource {
+	verification.Nonces = nil
+	return func(r *http.Request) bool {
+		if r.Header.Get("Signature") == "" {
+			return false
+		}
+		return verifier.VerifyRequest(r, verification) == nil
+	}
+}
+
+// TrustCIDRs trusts requests from client IPs in the networks (e.g. wireguard.Manager.Networks for WireGuard peers).
+// The client IP is taken from the connection, headers like X-Forwarded-For are not used.
+func TrustCIDRs(networks ...*net.IPNet) TrustSource {
+	return func(r *http.Request) bool {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return false
+		}
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// TrustClientCert trusts requests with a client certificate verified by the server (tls.Config.ClientCAs)
+func TrustClientCert() TrustSource {
+	return func(r *http.Request) bool {
+		return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+	}
+}
@@ -0,0 +1,130 @@
+package evasion
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedramktb/go-base-lib/auth"
+	"github.com/pedramktb/go-base-lib/auth/authtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTrustServer returns a handler that fails every request with ErrorHandler behind TrustMiddleware
+func newTrustServer(t *testing.T, sources ...TrustSource) http.Handler {
+	t.Helper()
+	setTestEnvironment(t, "prod")
+	setTestProfile(t, Profile{Code: FixedCode(http.StatusNotFound), Decoy: DecoyNginx})
+	return TrustMiddleware(sources...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Handler(errors.New("details"), w, r)
+	}))
+}
+
+func Test_TrustSignature(t *testing.T) {
+	signer, _ := authtest.NewED25519Signer(t)
+	verifier, err := auth.NewED25519Verifier(signer.PublicKey())
+	require.NoError(t, err)
+	nonces := auth.NewMemoryNonceStore()
+	verification := auth.HTTPVerification{Nonces: nonces, MaxBodySize: 16}
+
+	handler := newTrustServer(t, TrustSignature(verifier, verification))
+
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/path", strings.NewReader(body))
+		require.NoError(t, signer.SignRequest(req))
+		return req
+	}
+
+	// Signed requests get the error details
+	req := newRequest("body")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "details")
+
+	// The trust check does not consume the nonce of the request
+	assert.NoError(t, verifier.VerifyRequest(req, verification))
+
+	// Unsigned, tampered and too large requests get the profile
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "http://example.com/path", strings.NewReader("body")),
+		func() *http.Request {
+			req := newRequest("body")
+			req.URL.Path = "/other"
+			return req
+		}(),
+		newRequest(strings.Repeat("a", 32)),
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "nginx", rec.Header().Get("Server"))
+		assert.NotContains(t, rec.Body.String(), "details")
+	}
+}
+
+func Test_TrustCIDRs(t *testing.T) {
+	_, network, err := net.ParseCIDR("10.0.0.0/24")
+	require.NoError(t, err)
+	_, network6, err := net.ParseCIDR("fd00::/64")
+	require.NoError(t, err)
+	handler := newTrustServer(t, TrustCIDRs(network, network6))
+
+	tests := []struct {
+		remoteAddr string
+		code       int
+	}{
+		{remoteAddr: "10.0.0.1:1234", code: http.StatusOK},
+		{remoteAddr: "[fd00::1]:1234", code: http.StatusOK},
+		{remoteAddr: "10.0.1.1:1234", code: http.StatusNotFound},
+		{remoteAddr: "invalid", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "10.0.0.1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
+
+func Test_TrustClientCert(t *testing.T) {
+	handler := newTrustServer(t, TrustClientCert())
+
+	tests := []struct {
+		name string
+		tls  *tls.ConnectionState
+		code int
+	}{
+		{name: "plain", code: http.StatusNotFound},
+		{name: "unverified", tls: &tls.ConnectionState{}, code: http.StatusNotFound},
+		{name: "verified", tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}, code: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.TLS = tt.tls
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
+
+func Test_WithTrusted(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, IsTrusted(req.Context()))
+	assert.True(t, IsTrusted(WithTrusted(req.Context(), true)))
+	assert.False(t, IsTrusted(WithTrusted(req.Context(), false)))
+}
@@ -4,32 +4,53 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"sync/atomic"
 
 	"github.com/pedramktb/go-base-lib/env"
 	slogctx "github.com/veqryn/slog-context"
 )
 
+// levelOverride overrides the environment based level of all loggers if set
+var levelOverride atomic.Pointer[slog.Level]
+
+// SetLevel overrides the environment based level of all loggers (including already created ones),
+// nil restores the environment based level
+func SetLevel(level *slog.Level) {
+	levelOverride.Store(level)
+}
+
+// WatchLevel sets the level of all loggers to the value of the environment variable (e.g. LOG_LEVEL=debug)
+// whenever it changes, unsetting it restores the environment based level
+func WatchLevel(w *env.Watcher, key string) error {
+	return env.Subscribe(w, key, SetLevel)
+}
+
+// leveler returns the overridden level if set and the environment based level otherwise
+type leveler slog.Level
+
+func (l leveler) Level() slog.Level {
+	if level := levelOverride.Load(); level != nil {
+		return *level
+	}
+	return slog.Level(l)
+}
+
 func handler(writer io.Writer) slog.Handler {
-	var handler slog.Handler
-	switch env.GetEnvironment() {
-	case env.EnvironmentLocal:
-		handler = slog.NewTextHandler(writer, &slog.HandlerOptions{
-			AddSource: true,
-			Level:     slog.LevelDebug,
-		})
-	case env.EnvironmentDev, env.EnvironmentStaging:
-		handler = slog.NewJSONHandler(writer, &slog.HandlerOptions{
-			AddSource: true,
-			Level:     slog.LevelDebug,
-		})
-	case env.EnvironmentProd:
-		handler = slog.NewJSONHandler(writer, &slog.HandlerOptions{
-			AddSource: false,
-			Level:     slog.LevelInfo,
-		})
+	traits := env.GetEnvironment().Traits()
+
+	level := slog.LevelInfo
+	if traits.VerboseLogging {
+		level = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{
+		AddSource: traits.VerboseLogging,
+		Level:     leveler(level),
 	}
 
-	return handler
+	if traits.TextLogging {
+		return slog.NewTextHandler(writer, opts)
+	}
+	return slog.NewJSONHandler(writer, opts)
 }
 
 func NewLoggerCtx(ctx context.Context, writer io.Writer, prependers ...slogctx.AttrExtractor) context.Context {
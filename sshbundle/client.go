@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
@@ -21,6 +22,8 @@ type SSHBundle struct {
 // New creates a new SSHBundle with the given IP, username, signer and or password, and optionally additional SSH keys
 // If only a signer or a password is provided, the provided method is used to authenticate.
 // If both a signer and a password are provided, the password is used to authenticate and the signer's public key along with any additional SSH keys are added to the server.
+// Instead of raw public keys, a CA can be trusted by passing auth.SSHCA.AuthorizedKeysLine as an additional SSH key
+// and signers with short-lived certificates (see auth.SSHCA.CertSigner) can be used from then on.
 func NewClient(ip, username string, sudo bool, signer ssh.Signer, password string, additionalSSHKeys ...string) (*SSHBundle, error) {
 	var config *ssh.ClientConfig
 	if signer != nil {
@@ -87,9 +90,10 @@ func addKeyToServer(ip, username string, signer ssh.Signer, tempPassword string,
 		keys += additionalSSHKeys[i] + "\n"
 	}
 
+	// Single quotes keep the quoted options of lines like cert-authority,principals="..." intact
 	out, err := sess.CombinedOutput(
 		`mkdir -p ~/.ssh;
-		echo "` + keys + `" >> ~/.ssh/authorized_keys;`,
+		echo '` + strings.ReplaceAll(keys, "'", `'\''`) + `' >> ~/.ssh/authorized_keys;`,
 	)
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, string(out))
@@ -19,6 +19,34 @@ type Manager struct {
 	dnsV4, dnsV6   *net.UDPAddr
 }
 
+// Config holds the parameters of NewManager and can be filled from environment variables using env.Load
+type Config struct {
+	InterfaceName string       `env:"INTERFACE_NAME" default:"wg0"`
+	PrivateKey    string       `env:"PRIVATE_KEY" required:"true" secret:"true"`
+	ListenPort    uint16       `env:"LISTEN_PORT" default:"51820"`
+	MTU           uint16       `env:"MTU" default:"1420"`
+	NetV4         *net.IPNet   `env:"NET_V4"`
+	NetV6         *net.IPNet   `env:"NET_V6"`
+	AddrV4        net.IP       `env:"ADDR_V4"`
+	AddrV6        net.IP       `env:"ADDR_V6"`
+	DNSV4         *net.UDPAddr `env:"DNS_V4"`
+	DNSV6         *net.UDPAddr `env:"DNS_V6"`
+}
+
+// NewManagerFromConfig creates a new Manager using the parameters in cfg
+func NewManagerFromConfig(ctx context.Context, cfg Config) (*Manager, error) {
+	return NewManager(
+		ctx,
+		cfg.InterfaceName,
+		cfg.PrivateKey,
+		cfg.ListenPort,
+		cfg.MTU,
+		cfg.NetV4, cfg.NetV6,
+		cfg.AddrV4, cfg.AddrV6,
+		cfg.DNSV4, cfg.DNSV6,
+	)
+}
+
 func NewManager(
 	ctx context.Context,
 	interfaceName,
@@ -69,6 +97,17 @@ func (m *Manager) PublicKey() string {
 	return m.privateKey.PublicKey().String()
 }
 
+// Networks returns the configured peer subnets (IPv4 and/or IPv6)
+func (m *Manager) Networks() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, network := range []*net.IPNet{m.netV4, m.netV6} {
+		if network != nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
 func (m *Manager) Add(publicKey, address string) error {
 	wgClient, err := wgctrl.New()
 	if err != nil {